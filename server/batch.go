@@ -0,0 +1,236 @@
+// 批量生成标题：接收 CSV 或 JSON 数组形式的 {theme, channel} 列表，逐行生成标题并以 CSV 或 JSON 返回
+package server
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 单次批量生成最多处理的行数
+const maxBatchRows = 200
+
+// 批量生成中的一行请求，Err 不为空表示该行在解析阶段已出错
+type batchRow struct {
+	Row     int
+	Request TitleRequest
+	Err     error
+}
+
+// 批量生成中一行的结果
+type batchTitleResult struct {
+	Row   int    `json:"row"` // 行号，从1开始，不含CSV表头
+	Theme string `json:"theme"`
+	*channelTitleResult
+}
+
+// CSV 结果的表头
+var batchCSVHeader = []string{"row", "theme", "channel", "status", "message", "title", "seed", "tags_field"}
+
+// 批量生成标题。
+// 请求体可以是 JSON 数组（[{"theme": "...", "channel": 1}]）、text/csv 正文，或 multipart 表单中名为 file 的 CSV 文件；
+// CSV 的列依次为 theme、channel，首行为 theme,channel 时作为表头跳过，有表头时还可以包含 episode、seed 列。
+// 批量生成不支持 AI 改写，设置了 rewrite 的行生成失败。
+// 默认返回 JSON，请求参数 format=csv 或 Accept 为 text/csv 时返回 CSV
+func generateTitleBatch(c *gin.Context) {
+	rows, err := parseBatchRows(c)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+	if len(rows) == 0 {
+		c.JSON(http.StatusOK, gin.H{
+			"status":  "error",
+			"message": "批量生成的内容不能为空",
+		})
+		return
+	}
+	if len(rows) > maxBatchRows {
+		c.JSON(http.StatusOK, gin.H{
+			"status":  "error",
+			"message": fmt.Sprintf("单次批量生成不能超过%d行", maxBatchRows),
+		})
+		return
+	}
+	// 频道、标签等数据只加载一次，所有行共用
+	data, err := loadTitleData()
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	results := make([]*batchTitleResult, 0, len(rows))
+	failed := 0
+	for _, row := range rows {
+		err := row.Err
+		if err == nil {
+			err = validateBatchRequest(&row.Request)
+		}
+		var result *titleResult
+		if err == nil {
+			result, err = generateTitles(&row.Request, data)
+		}
+		if err != nil {
+			failed++
+		}
+		results = append(results, &batchTitleResult{
+			Row:                row.Row,
+			Theme:              row.Request.Theme,
+			channelTitleResult: newChannelTitleResult(row.Request.Channel, result, err),
+		})
+	}
+
+	if c.Query("format") == "csv" || strings.Contains(c.GetHeader("Accept"), "text/csv") {
+		var buf bytes.Buffer
+		w := csv.NewWriter(&buf)
+		_ = w.Write(batchCSVHeader)
+		for _, item := range results {
+			seed := ""
+			if item.Status == "success" {
+				seed = strconv.FormatInt(item.Seed, 10)
+			}
+			_ = w.Write([]string{
+				strconv.Itoa(item.Row), item.Theme, strconv.Itoa(item.Channel),
+				item.Status, item.Message, item.Title, seed, item.TagsField,
+			})
+		}
+		w.Flush()
+		c.Header("Content-Disposition", `attachment; filename="titles.csv"`)
+		c.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": fmt.Sprintf("批量生成完成，成功%d行，失败%d行", len(results)-failed, failed),
+		"results": results,
+	})
+}
+
+// 校验批量生成中的单行请求，单个生成接口由 binding 标签完成的校验在这里手动进行
+func validateBatchRequest(req *TitleRequest) error {
+	if strings.TrimSpace(req.Theme) == "" {
+		return errors.New("主题不能为空")
+	}
+	if req.Channel <= 0 {
+		return errors.New("频道ID无效")
+	}
+	// 每行的 AI 改写都需要等待模型返回，整批依次执行可能耗时过长
+	if req.Rewrite {
+		return errors.New("批量生成不支持 AI 改写")
+	}
+	return nil
+}
+
+// 根据请求的 Content-Type 解析批量生成的各行
+func parseBatchRows(c *gin.Context) ([]*batchRow, error) {
+	switch c.ContentType() {
+	case "application/json":
+		// 不使用 ShouldBindJSON，避免某一行缺少必填字段导致整批失败，各行的校验由 validateBatchRequest 完成
+		var requests []TitleRequest
+		if err := json.NewDecoder(c.Request.Body).Decode(&requests); err != nil {
+			return nil, errors.New("错误的请求参数，请求体应为JSON数组")
+		}
+		rows := make([]*batchRow, 0, len(requests))
+		for i, req := range requests {
+			rows = append(rows, &batchRow{Row: i + 1, Request: req})
+		}
+		return rows, nil
+	case "multipart/form-data":
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			return nil, errors.New("请上传CSV文件")
+		}
+		file, err := fileHeader.Open()
+		if err != nil {
+			fmt.Printf("打开上传文件失败：%v\n", err)
+			return nil, errors.New("读取CSV文件失败")
+		}
+		defer file.Close()
+		return parseBatchCSV(file)
+	case "text/csv", "text/plain":
+		return parseBatchCSV(c.Request.Body)
+	default:
+		return nil, errors.New("不支持的请求格式，请上传CSV或JSON")
+	}
+}
+
+// 解析批量生成的 CSV。没有表头时按 theme、channel 的顺序读取；有表头时按列名读取
+func parseBatchCSV(r io.Reader) ([]*batchRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("CSV格式错误：%v", err)
+	}
+	// 去掉 Excel 等软件导出时添加的 UTF-8 BOM
+	if len(records) > 0 && len(records[0]) > 0 {
+		records[0][0] = strings.TrimPrefix(records[0][0], "\ufeff")
+	}
+	columns := map[string]int{"theme": 0, "channel": 1, "episode": -1, "seed": -1}
+	if len(records) > 0 {
+		header := make([]string, len(records[0]))
+		for i, cell := range records[0] {
+			header[i] = strings.ToLower(strings.TrimSpace(cell))
+		}
+		if slices.Contains(header, "theme") && slices.Contains(header, "channel") {
+			for name := range columns {
+				columns[name] = slices.Index(header, name)
+			}
+			records = records[1:]
+		}
+	}
+	cell := func(record []string, name string) string {
+		if i := columns[name]; i >= 0 && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	rows := make([]*batchRow, 0, len(records))
+	for _, record := range records {
+		// 跳过空行
+		if len(strings.Join(record, "")) == 0 {
+			continue
+		}
+		row := &batchRow{Row: len(rows) + 1}
+		rows = append(rows, row)
+		row.Request.Theme = cell(record, "theme")
+		channel, err := strconv.Atoi(cell(record, "channel"))
+		if err != nil {
+			row.Err = errors.New("频道ID必须是整数")
+			continue
+		}
+		row.Request.Channel = channel
+		if episode := cell(record, "episode"); episode != "" {
+			if row.Request.Episode, err = strconv.Atoi(episode); err != nil {
+				row.Err = errors.New("集数必须是整数")
+				continue
+			}
+		}
+		if seedStr := cell(record, "seed"); seedStr != "" {
+			seed, err := strconv.ParseInt(seedStr, 10, 64)
+			if err != nil {
+				row.Err = errors.New("随机种子必须是整数")
+				continue
+			}
+			row.Request.Seed = &seed
+		}
+	}
+	return rows, nil
+}
@@ -0,0 +1,645 @@
+// 标题生成：加载频道及标签数据、整理成生成服务（services.GenerateTitles）的输入，并记录生成历史与标签使用，供单个生成与批量生成共用
+package server
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"slices"
+	"strings"
+	"time"
+
+	mGorm "fswrhzl/ytb_title/server/gorm"
+	"fswrhzl/ytb_title/server/services"
+)
+
+// 标题生成结果
+type titleResult struct {
+	Message         string            `json:"message"`
+	Title           string            `json:"title"`
+	Titles          []string          `json:"titles"`
+	Requested       int               `json:"requested"`
+	Seed            int64             `json:"seed"`
+	Episode         int               `json:"episode"`           // 标题模板中使用的集数
+	HistoryIds      []int64           `json:"history_ids"`       // 各候选标题的历史记录ID，确认使用标题时使用；试运行时为空
+	TagsField       string            `json:"tags_field"`        // YouTube 上传页面的“标签”字段
+	TagsFieldLength int               `json:"tags_field_length"` // 按 YouTube 规则计算的标签字段长度
+	BannedWords     []bannedWordMatch `json:"banned_words"`      // 命中的禁用词：被屏蔽的主题内容及被舍弃的标签
+	Duplicates      []titleDuplicate  `json:"duplicates"`        // 与频道最近的历史标题重复或近似重复的候选标题
+	Rewritten       bool              `json:"rewritten"`         // 标题使用了 AI 改写后的主题
+	Explanation     *titleExplanation `json:"explanation,omitempty"`
+}
+
+// 与频道最近的历史标题重复或近似重复的候选标题
+type titleDuplicate struct {
+	Title   string           `json:"title"`
+	Exact   bool             `json:"exact"`   // 与某个历史标题完全相同
+	Matches []duplicateMatch `json:"matches"` // 按相似度从高到低排列
+}
+
+// 匹配的历史标题
+type duplicateMatch struct {
+	HistoryId  int64      `json:"history_id"`
+	Title      string     `json:"title"`
+	Similarity float64    `json:"similarity"` // 保留两位小数
+	Exact      bool       `json:"exact"`
+	UsedAt     *time.Time `json:"used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// 标题生成过程说明，请求设置了 explain 时返回，对应生成的第一个标题
+type titleExplanation struct {
+	Candidates []explainedTag `json:"candidates"` // 参与生成的候选标签池，置顶标签在前
+	Picked     []string       `json:"picked"`
+	Dropped    []droppedTag   `json:"dropped"` // 未被选中的标签及原因，包括未进入候选标签池的标签
+}
+
+// 候选标签池中的标签
+type explainedTag struct {
+	Name      string `json:"name"`
+	Weight    int    `json:"weight"`
+	GroupId   int64  `json:"group_id"`
+	Pinned    bool   `json:"pinned"`
+	Triggered bool   `json:"triggered"`
+}
+
+// 未被选中的标签
+type droppedTag struct {
+	Tag    string `json:"tag"`
+	Reason string `json:"reason"`
+}
+
+// 单个频道的生成结果，生成失败时只有 Channel、Status 及 Message（命中禁用词时还有 BannedWords），用于多频道生成及批量生成
+type channelTitleResult struct {
+	Channel         int               `json:"channel"`
+	Status          string            `json:"status"`
+	Message         string            `json:"message"`
+	Title           string            `json:"title,omitempty"`
+	Titles          []string          `json:"titles,omitempty"`
+	Seed            int64             `json:"seed,omitempty"`
+	Episode         int               `json:"episode,omitempty"`
+	HistoryIds      []int64           `json:"history_ids,omitempty"`
+	TagsField       string            `json:"tags_field,omitempty"`
+	TagsFieldLength int               `json:"tags_field_length,omitempty"`
+	BannedWords     []bannedWordMatch `json:"banned_words,omitempty"`
+	Duplicates      []titleDuplicate  `json:"duplicates,omitempty"`
+	Rewritten       bool              `json:"rewritten,omitempty"`
+	Explanation     *titleExplanation `json:"explanation,omitempty"`
+}
+
+// 将 generateTitles 的结果或错误转换为单个频道的生成结果
+func newChannelTitleResult(channel int, result *titleResult, err error) *channelTitleResult {
+	if err != nil {
+		item := &channelTitleResult{Channel: channel, Status: "error", Message: err.Error()}
+		var bannedErr *bannedWordsError
+		if errors.As(err, &bannedErr) {
+			item.BannedWords = bannedErr.Matches
+		}
+		return item
+	}
+	return &channelTitleResult{
+		Channel:         channel,
+		Status:          "success",
+		Message:         result.Message,
+		Title:           result.Title,
+		Titles:          result.Titles,
+		Seed:            result.Seed,
+		Episode:         result.Episode,
+		HistoryIds:      result.HistoryIds,
+		TagsField:       result.TagsField,
+		TagsFieldLength: result.TagsFieldLength,
+		BannedWords:     result.BannedWords,
+		Duplicates:      result.Duplicates,
+		Rewritten:       result.Rewritten,
+		Explanation:     result.Explanation,
+	}
+}
+
+// 命中的禁用词
+type bannedWordMatch struct {
+	Word   string `json:"word"`
+	Action string `json:"action"`
+	Source string `json:"source"`        // theme 或 tag
+	Tag    string `json:"tag,omitempty"` // 来源为 tag 时被舍弃的标签名
+}
+
+// 主题包含处理方式为 reject 的禁用词
+type bannedWordsError struct {
+	Matches []bannedWordMatch
+}
+
+func (e *bannedWordsError) Error() string {
+	words := make([]string, 0, len(e.Matches))
+	for _, match := range e.Matches {
+		if match.Action == services.BannedWordReject {
+			words = append(words, match.Word)
+		}
+	}
+	return "主题包含禁用词：" + strings.Join(words, "、")
+}
+
+// 从本地缓存获取频道列表，缓存中没有时从数据库加载
+func loadChannels() ([]*mGorm.ChannelResponse, error) {
+	var channels []*mGorm.ChannelResponse
+	channelsStr, err := localCache.GetWithAutoRefresh("channels", 10*time.Minute, func() (string, error) {
+		fmt.Println("本地缓存未发现channels数据，调用数据库获取channels数据")
+		channelsTmp, err := channelRepository.GetAllChannels()
+		if err != nil {
+			return "", err
+		}
+		channelsStrTmp, err := json.Marshal(channelsTmp)
+		if err != nil {
+			return "", err
+		}
+		return string(channelsStrTmp), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	_ = json.Unmarshal([]byte(channelsStr), &channels)
+	return channels, nil
+}
+
+// 从本地缓存获取标签列表，缓存中没有时从数据库加载
+func loadTags() ([]*mGorm.TagResponse, error) {
+	var tags []*mGorm.TagResponse
+	tagsStr, err := localCache.GetWithAutoRefresh("tags", 10*time.Minute, func() (string, error) {
+		fmt.Println("本地缓存未发现tags数据，调用数据库获取tags数据")
+		tagsTmp, err := tagRepository.ListTags()
+		if err != nil {
+			return "", err
+		}
+		tagsStrTmp, err := json.Marshal(tagsTmp)
+		if err != nil {
+			return "", err
+		}
+		return string(tagsStrTmp), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	_ = json.Unmarshal([]byte(tagsStr), &tags)
+	return tags, nil
+}
+
+// 生成标题所需的数据，由调用方一次性从缓存加载，批量生成时各行共用
+type titleData struct {
+	Channels    []*mGorm.ChannelResponse
+	Tags        []*mGorm.TagResponse
+	Rules       []*mGorm.TagRuleResponse
+	BannedWords []*mGorm.BannedWord
+	rewrites    map[rewriteKey]*themeRewrite // AI 改写的结果，同一请求中相同主题的改写只调用一次接口
+}
+
+// 从本地缓存加载生成标题所需的数据，返回的错误信息可直接展示给用户
+func loadTitleData() (*titleData, error) {
+	channels, err := loadChannels()
+	if err != nil {
+		fmt.Printf("获取频道列表失败：%v\n", err)
+		return nil, errors.New("无法获取频道数据，生成标题失败")
+	}
+	tags, err := loadTags()
+	if err != nil {
+		fmt.Printf("获取标签列表失败：%v\n", err)
+		return nil, errors.New("无法获取标签数据，生成标题失败")
+	}
+	rules, err := loadTagRules()
+	if err != nil {
+		fmt.Printf("获取标签兼容规则失败：%v\n", err)
+		return nil, errors.New("无法获取标签兼容规则数据，生成标题失败")
+	}
+	bannedWords, err := loadBannedWords()
+	if err != nil {
+		fmt.Printf("获取禁用词失败：%v\n", err)
+		return nil, errors.New("无法获取禁用词数据，生成标题失败")
+	}
+	return &titleData{Channels: channels, Tags: tags, Rules: rules, BannedWords: bannedWords}, nil
+}
+
+// 从本地缓存获取标签兼容规则，缓存中没有时从数据库加载
+func loadTagRules() ([]*mGorm.TagRuleResponse, error) {
+	var rules []*mGorm.TagRuleResponse
+	rulesStr, err := localCache.GetWithAutoRefresh("tag_rules", 10*time.Minute, func() (string, error) {
+		fmt.Println("本地缓存未发现tag_rules数据，调用数据库获取tag_rules数据")
+		rulesTmp, err := tagRuleRepository.ListTagRules()
+		if err != nil {
+			return "", err
+		}
+		rulesStrTmp, err := json.Marshal(rulesTmp)
+		if err != nil {
+			return "", err
+		}
+		return string(rulesStrTmp), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	_ = json.Unmarshal([]byte(rulesStr), &rules)
+	return rules, nil
+}
+
+// 从本地缓存获取禁用词，缓存中没有时从数据库加载
+func loadBannedWords() ([]*mGorm.BannedWord, error) {
+	var words []*mGorm.BannedWord
+	wordsStr, err := localCache.GetWithAutoRefresh("banned_words", 10*time.Minute, func() (string, error) {
+		fmt.Println("本地缓存未发现banned_words数据，调用数据库获取banned_words数据")
+		wordsTmp, err := bannedWordRepository.ListBannedWords()
+		if err != nil {
+			return "", err
+		}
+		wordsStrTmp, err := json.Marshal(wordsTmp)
+		if err != nil {
+			return "", err
+		}
+		return string(wordsStrTmp), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	_ = json.Unmarshal([]byte(wordsStr), &words)
+	return words, nil
+}
+
+// 为一个生成请求生成标题，返回的错误信息可直接展示给用户
+func generateTitles(titleRequest *TitleRequest, data *titleData) (*titleResult, error) {
+	count := titleRequest.Count
+	if count == 0 {
+		count = 1
+	}
+	if count < 0 || count > maxTitleCount {
+		return nil, fmt.Errorf("候选标题数量必须在1到%d之间", maxTitleCount)
+	}
+	var channel *mGorm.ChannelResponse
+	for _, ch := range data.Channels {
+		if ch.Id == int64(titleRequest.Channel) {
+			channel = ch
+			break
+		}
+	}
+	if channel == nil {
+		return nil, errors.New("频道不存在")
+	}
+	// 按频道设置的方式计算主题及标题长度
+	measurer, err := services.NewLengthMeasurer(channel.LengthMetric)
+	if err != nil {
+		fmt.Printf("获取长度计算方式失败：%v\n", err)
+		measurer, _ = services.NewLengthMeasurer(services.LengthMetricRunes)
+	}
+	limits := services.TitleLimits{
+		MaxTitleLength: channel.MaxTitleLength,
+		MaxThemeLength: channel.MaxThemeLength,
+		MaxHashtags:    channel.MaxHashtags,
+	}
+	if maxLength := limits.ThemeLength(); measurer.Measure(titleRequest.Theme) > maxLength {
+		return nil, fmt.Errorf("主题长度不能超过%d个字符", maxLength)
+	}
+	// 使用集数计数器且未指定集数时，使用计数器的下一集；计数器只在确认使用标题时增加
+	episode := titleRequest.Episode
+	counterName := ""
+	if titleRequest.Counter != "" {
+		counterName, err = mGorm.NormalizeCounterName(titleRequest.Counter)
+		if err != nil {
+			return nil, err
+		}
+		if episode == 0 {
+			value, err := episodeCounterRepository.GetEpisodeCounter(channel.Id, counterName)
+			if err != nil {
+				return nil, err
+			}
+			episode = value + 1
+		}
+	}
+
+	// 检查主题中的禁用词（全局及本频道），命中 reject 时拒绝生成，命中 mask 时屏蔽
+	bannedWords := make([]services.BannedWord, 0)
+	for _, word := range data.BannedWords {
+		if word.ChannelId == 0 || word.ChannelId == channel.Id {
+			bannedWords = append(bannedWords, services.BannedWord{Word: word.Word, Action: word.Action})
+		}
+	}
+	bannedMatches := make([]bannedWordMatch, 0)
+	theme, matched := services.FilterBannedWords(titleRequest.Theme, bannedWords)
+	rejected := false
+	for _, word := range matched {
+		bannedMatches = append(bannedMatches, bannedWordMatch{Word: word.Word, Action: word.Action, Source: "theme"})
+		rejected = rejected || word.Action == services.BannedWordReject
+	}
+	if rejected {
+		return nil, &bannedWordsError{Matches: bannedMatches}
+	}
+
+	decoration := services.TitleDecoration{
+		Prefix:           channel.TitlePrefix,
+		Suffix:           channel.TitleSuffix,
+		HashtagSeparator: channel.HashtagSeparator,
+		HashtagPlacement: channel.HashtagPlacement,
+		HashtagCase:      channel.HashtagCase,
+	}
+	needTags := make([]services.TagCandidate, 0)
+	// 置顶标签按置顶顺序排列，不参与随机抽样
+	pinnedTags := make([]services.TagCandidate, len(channel.PinnedTags))
+	// 未进入候选标签池的标签，用于生成过程说明
+	dropped := make([]droppedTag, 0)
+	triggerKeywords := make([]string, 0)
+	// 已进入候选的标签名（小写），译名相同的标签只保留一个
+	candidateNames := make(map[string]bool)
+	for _, tag := range data.Tags {
+		if !slices.Contains(channel.Tags, tag.Id) {
+			dropped = append(dropped, droppedTag{Tag: tag.Name, Reason: "未关联该频道"})
+		}
+	}
+	for _, tagId := range channel.Tags {
+		for _, tag := range data.Tags {
+			if tag.Id == int64(tagId) {
+				// 置顶标签总是放入标题，不受独占标签的限制；包含禁用词时无法生成
+				pinIndex := slices.Index(channel.PinnedTags, tag.Id)
+				// 主题命中触发词的标签优先使用，未命中的独占标签不参与生成
+				triggered := services.MatchTriggers(theme, tag.Triggers)
+				if tag.Exclusive && !triggered && pinIndex < 0 {
+					dropped = append(dropped, droppedTag{Tag: tag.Name, Reason: "独占标签，主题未命中触发词"})
+					continue
+				}
+				// 使用标签在频道语言下的译名，没有译名时使用原名
+				name := decoration.ApplyCase(services.LocalizedTagName(tag.Name, tag.Translations, channel.Locale))
+				// 包含禁用词的标签不参与生成
+				if _, matched := services.FilterBannedWords(name, bannedWords); len(matched) > 0 {
+					words := make([]string, 0, len(matched))
+					for _, word := range matched {
+						bannedMatches = append(bannedMatches, bannedWordMatch{Word: word.Word, Action: word.Action, Source: "tag", Tag: name})
+						words = append(words, word.Word)
+					}
+					if pinIndex >= 0 {
+						return nil, fmt.Errorf("置顶标签 #%s 包含禁用词：%s，请取消置顶或修改禁用词", name, strings.Join(words, "、"))
+					}
+					dropped = append(dropped, droppedTag{Tag: name, Reason: "包含禁用词：" + strings.Join(words, "、")})
+					continue
+				}
+				// 译名与其他标签相同时（修改译名时会校验，此处处理已有的数据）只保留第一个
+				if candidateNames[strings.ToLower(name)] {
+					if pinIndex >= 0 {
+						return nil, fmt.Errorf("置顶标签 #%s 的名称与其他标签重复，请修改标签译名", name)
+					}
+					dropped = append(dropped, droppedTag{Tag: name, Reason: "名称与其他标签重复"})
+					continue
+				}
+				candidateNames[strings.ToLower(name)] = true
+				// 主题命中的触发词作为 YouTube 标签字段中的短语
+				if triggered {
+					triggerKeywords = append(triggerKeywords, services.MatchedTriggerKeywords(theme, tag.Triggers)...)
+				}
+				weight, ok := channel.TagWeights[tag.Id]
+				if !ok || weight <= 0 {
+					weight = mGorm.DefaultTagWeight
+				}
+				wTag := services.TagCandidate{Id: tag.Id, Name: name, Weight: weight, GroupId: tag.GroupId, Triggered: triggered}
+				if pinIndex >= 0 {
+					pinnedTags[pinIndex] = wTag
+				} else {
+					needTags = append(needTags, wTag)
+				}
+			}
+		}
+	}
+	// 去掉已被删除、在标签列表中找不到的置顶标签
+	pinnedTags = slices.DeleteFunc(pinnedTags, func(tag services.TagCandidate) bool {
+		return tag.Name == ""
+	})
+	tagIdByName := make(map[string]int64)
+	for _, tag := range slices.Concat(pinnedTags, needTags) {
+		tagIdByName[tag.Name] = tag.Id
+	}
+	// 候选标签按名称排序（名称相同时按ID），保证相同的种子总能得到相同的抽样结果，不受数据库返回顺序影响
+	slices.SortFunc(needTags, func(a, b services.TagCandidate) int {
+		return cmp.Or(strings.Compare(a.Name, b.Name), cmp.Compare(a.Id, b.Id))
+	})
+	// 频道开启了近期标签回避时，按最近几次生成中的使用次数降低候选标签的权重。
+	// 回避在抽样之前完成，指定种子时同样生效；近期使用记录不变时，相同的种子仍得到相同的标题。
+	// 降低后的权重只用于抽样，标签字段仍按频道设置的权重排列
+	candidates := needTags
+	if channel.RecentWindow > 0 {
+		recentUsage, err := tagUsageRepository.RecentTagUsage(channel.Id, channel.RecentWindow)
+		if err != nil {
+			fmt.Printf("获取频道近期标签失败：%v\n", err)
+		} else {
+			candidates = services.PenalizeRecentTags(needTags, recentUsage)
+		}
+	}
+	quotas := make([]services.GroupQuota, 0, len(channel.GroupQuotas))
+	for _, quota := range channel.GroupQuotas {
+		quotas = append(quotas, services.GroupQuota{GroupId: quota.GroupId, Min: quota.Min, Max: quota.Max})
+	}
+	rules := make([]services.TagRule, 0, len(data.Rules))
+	for _, rule := range data.Rules {
+		rules = append(rules, services.TagRule{
+			TagId:      rule.TagId,
+			TagName:    rule.TagName,
+			Kind:       rule.Kind,
+			TargetId:   rule.TargetId,
+			TargetName: rule.TargetName,
+		})
+	}
+
+	tpl, err := parseTitleTemplate(channel.TitleTemplate)
+	if err != nil {
+		fmt.Printf("解析频道标题模板失败：%v\n", err)
+		return nil, errors.New("频道标题模板格式错误，生成标题失败")
+	}
+	// 按主题创建渲染函数，AI 改写后的主题使用相同的模板数据
+	date := time.Now().Format("2006-01-02")
+	newRender := func(theme string) (func(hashtags string) string, error) {
+		return newTitleRenderer(tpl, titleTemplateData{Theme: theme, Episode: episode, Date: date}, decoration)
+	}
+	render, err := newRender(theme)
+	if err != nil {
+		return nil, err
+	}
+	spec := &services.TitleSpec{
+		Render:     render,
+		Measurer:   measurer,
+		Pinned:     pinnedTags,
+		Candidates: candidates,
+		Quotas:     quotas,
+		Rules:      services.NewTagRules(rules),
+		Strategy:   channel.TitleStrategy,
+		Separator:  decoration.Separator(),
+		Limits:     limits,
+	}
+
+	var seed int64
+	if titleRequest.Seed != nil {
+		seed = *titleRequest.Seed
+	} else {
+		seed = rand.Int63n(maxSeed)
+	}
+
+	// 使用 AI 改写主题时，为每个改写后的主题各生成一个标题，改写失败时按原主题生成
+	var generated []services.GeneratedTitle
+	var rewrittenThemes []string // 与 generated 一一对应的改写后的主题
+	rewriteMessage := ""
+	rewritten := false
+	if titleRequest.Rewrite {
+		rewrittenTitles, themes, rewrittenSpec, err := generateRewrittenTitles(data, theme, count, seed, spec, limits, bannedWords, newRender)
+		if err != nil {
+			rewriteMessage = err.Error() + "，已按原主题生成"
+		} else {
+			generated, rewrittenThemes, spec, rewritten = rewrittenTitles, themes, rewrittenSpec, true
+		}
+	}
+	if generated == nil {
+		// 生成多个候选标题，标签组合相同的候选只保留一个
+		generated, err = services.GenerateTitles(spec, count, seed)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var explanation *titleExplanation
+	if titleRequest.Explain {
+		explained, err := services.ExplainTitle(spec, seed)
+		if err != nil {
+			return nil, err
+		}
+		explanation = &titleExplanation{Candidates: make([]explainedTag, 0), Picked: explained.Picked, Dropped: dropped}
+		for _, tag := range slices.Concat(pinnedTags, candidates) {
+			explanation.Candidates = append(explanation.Candidates, explainedTag{
+				Name:      tag.Name,
+				Weight:    tag.Weight,
+				GroupId:   tag.GroupId,
+				Pinned:    slices.ContainsFunc(pinnedTags, func(t services.TagCandidate) bool { return t.Id == tag.Id }),
+				Triggered: tag.Triggered,
+			})
+		}
+		for _, tag := range explained.Dropped {
+			explanation.Dropped = append(explanation.Dropped, droppedTag{Tag: tag.Name, Reason: tag.Reason})
+		}
+	}
+	titles := make([]string, 0, len(generated))
+	histories := make([]*mGorm.TitleHistory, 0, len(generated))
+	duplicates := findDuplicateTitles(channel.Id, generated, titleRequest.UsedOnly)
+	for i, g := range generated {
+		titles = append(titles, g.Title)
+		// 历史中保存标题实际使用的主题，AI 改写的标题保存改写后的主题
+		historyTheme := theme
+		if rewritten {
+			historyTheme = rewrittenThemes[i]
+		}
+		histories = append(histories, &mGorm.TitleHistory{
+			ChannelId: channel.Id,
+			Theme:     historyTheme,
+			Tags:      strings.Join(g.Tags, ","),
+			Title:     g.Title,
+			Seed:      seed,
+			Counter:   counterName,
+			Episode:   episode,
+			CreatedAt: time.Now(),
+		})
+	}
+	firstPicked := generated[0].Tags
+	// 保存生成历史，失败不影响本次生成结果；explain 为试运行，不保存历史及标签使用
+	historyIds := make([]int64, 0, len(histories))
+	if !titleRequest.Explain {
+		if err := historyRepository.CreateHistory(histories); err != nil {
+			fmt.Printf("保存标题历史失败：%v\n", err)
+		} else {
+			for _, history := range histories {
+				historyIds = append(historyIds, history.Id)
+			}
+		}
+	}
+
+	// 生成 YouTube 标签字段：优先使用第一个标题选中的标签及主题命中的触发词，其次按权重从高到低使用频道的其他标签，最后是主题本身及其中的单词
+	fieldTags := slices.Clone(firstPicked)
+	for _, tag := range pinnedTags {
+		fieldTags = append(fieldTags, tag.Name)
+	}
+	fieldTags = append(fieldTags, triggerKeywords...)
+	byWeight := slices.Clone(needTags)
+	slices.SortStableFunc(byWeight, func(a, b services.TagCandidate) int {
+		return b.Weight - a.Weight
+	})
+	for _, tag := range byWeight {
+		fieldTags = append(fieldTags, tag.Name)
+	}
+	tagsField, tagsFieldLength := services.BuildTagsField(fieldTags, theme)
+
+	// 记录本次使用的标签（以第一个候选标题为准），供后续生成回避近期标签。
+	// 确认使用标题是可选的，因此在生成时记录：每次为频道生成（多频道生成中的每个频道、批量生成中的每一行）各记录一次，试运行不记录
+	usedTagIds := make([]int64, 0, len(firstPicked))
+	for _, name := range firstPicked {
+		usedTagIds = append(usedTagIds, tagIdByName[name])
+	}
+	if !titleRequest.Explain {
+		if err := tagUsageRepository.RecordTagUsage(channel.Id, usedTagIds); err != nil {
+			fmt.Printf("记录频道标签使用失败：%v\n", err)
+		}
+	}
+
+	message := "生成标题成功"
+	if rewriteMessage != "" {
+		message = rewriteMessage
+	} else if len(titles) < count && titleRequest.Rewrite {
+		message = fmt.Sprintf("AI 改写仅得到%d个可用的主题", len(titles))
+	} else if len(titles) < count {
+		message = fmt.Sprintf("频道标签数量不足，仅生成了%d个不重复的标题", len(titles))
+	}
+	return &titleResult{
+		Message:         message,
+		Title:           titles[0],
+		Titles:          titles,
+		Requested:       count,
+		Seed:            seed,
+		Episode:         episode,
+		HistoryIds:      historyIds,
+		TagsField:       tagsField,
+		TagsFieldLength: tagsFieldLength,
+		BannedWords:     bannedMatches,
+		Duplicates:      duplicates,
+		Rewritten:       rewritten,
+		Explanation:     explanation,
+	}, nil
+}
+
+// 将生成的标题与频道最近生成的历史标题比较，返回完全相同或近似重复的标题；usedOnly 为 true 时只与已确认使用的标题比较。
+// 需在保存本次生成的历史之前调用；查询历史失败时不影响生成结果，返回空列表
+func findDuplicateTitles(channelId int64, generated []services.GeneratedTitle, usedOnly bool) []titleDuplicate {
+	duplicates := make([]titleDuplicate, 0)
+	histories, err := historyRepository.RecentHistory(channelId, duplicateHistorySize, usedOnly)
+	if err != nil {
+		fmt.Printf("获取频道历史标题失败：%v\n", err)
+		return duplicates
+	}
+	// 相同的历史标题只比较最近的一条
+	previous := make([]*mGorm.TitleHistory, 0, len(histories))
+	previousTitles := make([]string, 0, len(histories))
+	seen := make(map[string]bool)
+	for _, history := range histories {
+		if !seen[history.Title] {
+			seen[history.Title] = true
+			previous = append(previous, history)
+			previousTitles = append(previousTitles, history.Title)
+		}
+	}
+	for _, g := range generated {
+		similar := services.FindSimilarTitles(g.Title, previousTitles, services.DuplicateSimilarityThreshold)
+		if len(similar) == 0 {
+			continue
+		}
+		duplicate := titleDuplicate{Title: g.Title, Matches: make([]duplicateMatch, 0, len(similar))}
+		for _, s := range similar {
+			history := previous[s.Index]
+			duplicate.Exact = duplicate.Exact || s.Exact
+			duplicate.Matches = append(duplicate.Matches, duplicateMatch{
+				HistoryId:  history.Id,
+				Title:      history.Title,
+				Similarity: math.Round(s.Similarity*100) / 100,
+				Exact:      s.Exact,
+				UsedAt:     history.UsedAt,
+				CreatedAt:  history.CreatedAt,
+			})
+		}
+		duplicates = append(duplicates, duplicate)
+	}
+	return duplicates
+}
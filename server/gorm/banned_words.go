@@ -0,0 +1,76 @@
+// 禁用词数据操作
+package gorm
+
+import (
+	"errors"
+	"log"
+	"strings"
+
+	"fswrhzl/ytb_title/server/services"
+
+	"gorm.io/gorm"
+)
+
+type BannedWordRepository interface {
+	// 获取所有禁用词
+	ListBannedWords() ([]*BannedWord, error)
+	// 创建禁用词
+	CreateBannedWord(bwcr *BannedWordCreateRequest) error
+	// 删除禁用词
+	DeleteBannedWord(id int) error
+}
+
+type bannedWordRepository struct {
+	db *gorm.DB
+}
+
+func NewBannedWordRepository() BannedWordRepository {
+	return &bannedWordRepository{db: DB}
+}
+
+func (r *bannedWordRepository) ListBannedWords() ([]*BannedWord, error) {
+	var words []*BannedWord
+	if err := r.db.Order("channel_id, word").Find(&words).Error; err != nil {
+		log.Printf("查询禁用词失败：%v", err)
+		return nil, errors.New("查询禁用词失败")
+	}
+	return words, nil
+}
+
+func (r *bannedWordRepository) CreateBannedWord(bwcr *BannedWordCreateRequest) error {
+	// 按匹配时的规则规范化，保证同一个词只保存一次
+	word, err := services.NormalizeBannedWord(bwcr.Word)
+	if err != nil {
+		return err
+	}
+	action := bwcr.Action
+	if action == "" {
+		action = services.BannedWordReject
+	}
+	if action != services.BannedWordReject && action != services.BannedWordMask {
+		return errors.New("禁用词的处理方式只能是reject或mask")
+	}
+	if bwcr.ChannelId != 0 {
+		if err := r.db.First(&Channel{}, bwcr.ChannelId).Error; err != nil {
+			log.Printf("查询频道失败：%v", err)
+			return errors.New("频道不存在")
+		}
+	}
+	bannedWord := BannedWord{Word: word, ChannelId: bwcr.ChannelId, Action: action}
+	if err := r.db.Create(&bannedWord).Error; err != nil {
+		log.Printf("创建禁用词失败：%v", err)
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return errors.New("禁用词已存在")
+		}
+		return errors.New("创建禁用词失败")
+	}
+	return nil
+}
+
+func (r *bannedWordRepository) DeleteBannedWord(id int) error {
+	if err := r.db.Delete(&BannedWord{}, id).Error; err != nil {
+		log.Printf("删除禁用词失败：%v", err)
+		return errors.New("删除禁用词失败")
+	}
+	return nil
+}
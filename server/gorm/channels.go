@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"log"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -33,7 +34,7 @@ func NewChannelRepository() ChannelRepository {
 func (r *channelRepository) GetAllChannels() ([]*ChannelResponse, error) {
 	var channels []*ChannelResponse
 	rows, err := r.db.Table("channels AS c").
-		Select("c.id, c.name, c.default_title, GROUP_CONCAT(ct.tag_id, ',') AS tagListStr").
+		Select("c.id, c.name, c.default_title, c.title_template, c.length_metric, c.recent_window, c.title_strategy, c.locale, c.title_prefix, c.title_suffix, c.hashtag_separator, c.hashtag_placement, c.hashtag_case, c.max_title_length, c.max_theme_length, c.max_hashtags, GROUP_CONCAT(ct.tag_id || ':' || IFNULL(ct.weight, 0) || ':' || IFNULL(ct.pin_order, 0), ',') AS tagListStr").
 		Joins(" left join channel_tag AS ct on c.id = ct.channel_id").
 		Group("c.id").
 		Rows()
@@ -47,7 +48,16 @@ func (r *channelRepository) GetAllChannels() ([]*ChannelResponse, error) {
 		// 数据库中的null不对应任何go中的数据类型，需要特殊处理，使用sql.NullString类型接收
 		var tagListStrTmp sql.NullString
 		var defaultTitleTmp sql.NullString
-		if err := rows.Scan(&channel.Id, &channel.Name, &defaultTitleTmp, &tagListStrTmp); err != nil {
+		var titleTemplateTmp sql.NullString
+		var lengthMetricTmp sql.NullString
+		var recentWindowTmp sql.NullInt64
+		var titleStrategyTmp sql.NullString
+		var localeTmp sql.NullString
+		var decorationTmp [5]sql.NullString
+		var limitsTmp [3]sql.NullInt64
+		if err := rows.Scan(&channel.Id, &channel.Name, &defaultTitleTmp, &titleTemplateTmp, &lengthMetricTmp, &recentWindowTmp, &titleStrategyTmp, &localeTmp,
+			&decorationTmp[0], &decorationTmp[1], &decorationTmp[2], &decorationTmp[3], &decorationTmp[4],
+			&limitsTmp[0], &limitsTmp[1], &limitsTmp[2], &tagListStrTmp); err != nil {
 			return nil, errors.New("数据解析失败")
 		}
 
@@ -56,6 +66,31 @@ func (r *channelRepository) GetAllChannels() ([]*ChannelResponse, error) {
 		} else {
 			channel.DefaultTitle = ""
 		}
+		if titleTemplateTmp.Valid {
+			channel.TitleTemplate = titleTemplateTmp.String
+		}
+		if lengthMetricTmp.Valid {
+			channel.LengthMetric = lengthMetricTmp.String
+		}
+		if recentWindowTmp.Valid {
+			channel.RecentWindow = int(recentWindowTmp.Int64)
+		}
+		if titleStrategyTmp.Valid {
+			channel.TitleStrategy = titleStrategyTmp.String
+		}
+		if localeTmp.Valid {
+			channel.Locale = localeTmp.String
+		}
+		// 标题装饰设置，顺序与查询的列一致；NULL 对应空字符串
+		channel.TitlePrefix = decorationTmp[0].String
+		channel.TitleSuffix = decorationTmp[1].String
+		channel.HashtagSeparator = decorationTmp[2].String
+		channel.HashtagPlacement = decorationTmp[3].String
+		channel.HashtagCase = decorationTmp[4].String
+		// 长度限制，顺序与查询的列一致；NULL 对应0（使用默认值）
+		channel.MaxTitleLength = int(limitsTmp[0].Int64)
+		channel.MaxThemeLength = int(limitsTmp[1].Int64)
+		channel.MaxHashtags = int(limitsTmp[2].Int64)
 
 		var tagListStr string
 		if tagListStrTmp.Valid { // 如果不为null
@@ -64,29 +99,60 @@ func (r *channelRepository) GetAllChannels() ([]*ChannelResponse, error) {
 			tagListStr = ""
 		}
 
-		// 切割字符串，循环获取tag id
+		// 切割字符串，循环获取tag id、权重及置顶顺序，格式为 "tagId:weight:pinOrder,tagId:weight:pinOrder"
+		channel.TagWeights = make(map[int64]int)
+		pinOrders := make(map[int64]int)
 		for len(tagListStr) > 0 {
-			tagId, rest, found := strings.Cut(tagListStr, ",")
+			tagPair, rest, found := strings.Cut(tagListStr, ",")
+			tagId, weightAndPin, _ := strings.Cut(tagPair, ":")
+			weight, pinOrder, _ := strings.Cut(weightAndPin, ":")
 			tagIdInt, err := strconv.Atoi(tagId)
 			if err != nil {
 				return nil, errors.New("标签ID转换失败")
 			}
+			weightInt, err := strconv.Atoi(weight)
+			if err != nil || weightInt <= 0 {
+				weightInt = DefaultTagWeight
+			}
 			channel.Tags = append(channel.Tags, int64(tagIdInt))
+			channel.TagWeights[int64(tagIdInt)] = weightInt
+			if pinOrderInt, err := strconv.Atoi(pinOrder); err == nil && pinOrderInt > 0 {
+				channel.PinnedTags = append(channel.PinnedTags, int64(tagIdInt))
+				pinOrders[int64(tagIdInt)] = pinOrderInt
+			}
 			if !found {
 				break
 			}
 			tagListStr = rest
 		}
+		slices.SortFunc(channel.PinnedTags, func(a, b int64) int {
+			return pinOrders[a] - pinOrders[b]
+		})
 		channels = append(channels, &channel)
 	}
 	if err := rows.Err(); err != nil {
 		return nil, errors.New("迭代频道行失败")
 	}
+	// 查询频道的标签分组配额
+	var quotas []*ChannelGroupQuota
+	if err := r.db.Order("group_id").Find(&quotas).Error; err != nil {
+		log.Printf("查询频道分组配额失败：%v", err)
+		return nil, errors.New("查询频道分组配额失败")
+	}
+	for _, channel := range channels {
+		for _, quota := range quotas {
+			if quota.ChannelId == channel.Id {
+				channel.GroupQuotas = append(channel.GroupQuotas, GroupQuota{GroupId: quota.GroupId, Min: quota.Min, Max: quota.Max})
+			}
+		}
+	}
 	return channels, nil
 }
 
 func (r *channelRepository) CreateChannel(ccr *ChannelCreateRequest) error {
-	var channel Channel = Channel{Name: ccr.Name, DefaultTitle: ccr.DefaultTitle}
+	var channel Channel = Channel{Name: ccr.Name, DefaultTitle: ccr.DefaultTitle, TitleTemplate: ccr.TitleTemplate, LengthMetric: ccr.LengthMetric, RecentWindow: ccr.RecentWindow, TitleStrategy: ccr.TitleStrategy, Locale: ccr.Locale,
+		TitlePrefix: ccr.TitlePrefix, TitleSuffix: ccr.TitleSuffix, HashtagSeparator: ccr.HashtagSeparator, HashtagPlacement: ccr.HashtagPlacement, HashtagCase: ccr.HashtagCase,
+		MaxTitleLength: ccr.MaxTitleLength, MaxThemeLength: ccr.MaxThemeLength, MaxHashtags: ccr.MaxHashtags}
 	// 引入事务
 	err := r.db.Transaction(func(tx *gorm.DB) error {
 		result := tx.Create(&channel)
@@ -98,12 +164,20 @@ func (r *channelRepository) CreateChannel(ccr *ChannelCreateRequest) error {
 			return errors.New("新增频道失败")
 		}
 		for _, tagId := range ccr.Tags {
-			result := tx.Create(&ChannelTag{ChannelId: channel.Id, TagId: tagId})
+			pinOrder := slices.Index(ccr.PinnedTags, tagId) + 1
+			result := tx.Create(&ChannelTag{ChannelId: channel.Id, TagId: tagId, Weight: ccr.TagWeights[tagId], PinOrder: pinOrder})
 			if result.Error != nil {
 				log.Printf("插入频道标签失败：%v", result.Error)
 				return errors.New("新增频道标签失败")
 			}
 		}
+		for _, quota := range ccr.GroupQuotas {
+			result := tx.Create(&ChannelGroupQuota{ChannelId: channel.Id, GroupId: quota.GroupId, Min: quota.Min, Max: quota.Max})
+			if result.Error != nil {
+				log.Printf("插入频道分组配额失败：%v", result.Error)
+				return errors.New("新增频道分组配额失败")
+			}
+		}
 		return nil
 	})
 	if err != nil {
@@ -114,7 +188,9 @@ func (r *channelRepository) CreateChannel(ccr *ChannelCreateRequest) error {
 }
 
 func (r *channelRepository) UpdateChannel(cur *ChannelUpdateRequest) error {
-	var channel Channel = Channel{Id: cur.Id, Name: cur.Name, DefaultTitle: cur.DefaultTitle}
+	var channel Channel = Channel{Id: cur.Id, Name: cur.Name, DefaultTitle: cur.DefaultTitle, TitleTemplate: cur.TitleTemplate, LengthMetric: cur.LengthMetric, RecentWindow: cur.RecentWindow, TitleStrategy: cur.TitleStrategy, Locale: cur.Locale,
+		TitlePrefix: cur.TitlePrefix, TitleSuffix: cur.TitleSuffix, HashtagSeparator: cur.HashtagSeparator, HashtagPlacement: cur.HashtagPlacement, HashtagCase: cur.HashtagCase,
+		MaxTitleLength: cur.MaxTitleLength, MaxThemeLength: cur.MaxThemeLength, MaxHashtags: cur.MaxHashtags}
 	// 引入事务
 	err := r.db.Transaction(func(tx *gorm.DB) error {
 		// Save方法默认使用id作为条件，更新其他字段
@@ -129,12 +205,25 @@ func (r *channelRepository) UpdateChannel(cur *ChannelUpdateRequest) error {
 			return errors.New("删除频道标签失败")
 		}
 		for _, tagId := range cur.Tags {
-			result := tx.Create(&ChannelTag{ChannelId: cur.Id, TagId: tagId})
+			pinOrder := slices.Index(cur.PinnedTags, tagId) + 1
+			result := tx.Create(&ChannelTag{ChannelId: cur.Id, TagId: tagId, Weight: cur.TagWeights[tagId], PinOrder: pinOrder})
 			if result.Error != nil {
 				log.Printf("插入频道标签失败：%v", result.Error)
 				return errors.New("插入频道标签失败")
 			}
 		}
+		result = tx.Delete(&ChannelGroupQuota{}, "channel_id = ?", cur.Id)
+		if result.Error != nil {
+			log.Printf("删除频道分组配额失败：%v", result.Error)
+			return errors.New("删除频道分组配额失败")
+		}
+		for _, quota := range cur.GroupQuotas {
+			result := tx.Create(&ChannelGroupQuota{ChannelId: cur.Id, GroupId: quota.GroupId, Min: quota.Min, Max: quota.Max})
+			if result.Error != nil {
+				log.Printf("插入频道分组配额失败：%v", result.Error)
+				return errors.New("插入频道分组配额失败")
+			}
+		}
 		return nil
 	})
 	if err != nil {
@@ -156,6 +245,21 @@ func (r *channelRepository) DeleteChannel(id int) error {
 			log.Printf("删除频道标签失败：%v", result.Error)
 			return errors.New("删除频道标签失败")
 		}
+		result = tx.Delete(&ChannelGroupQuota{}, "channel_id = ?", id)
+		if result.Error != nil {
+			log.Printf("删除频道分组配额失败：%v", result.Error)
+			return errors.New("删除频道分组配额失败")
+		}
+		result = tx.Delete(&BannedWord{}, "channel_id = ?", id)
+		if result.Error != nil {
+			log.Printf("删除频道禁用词失败：%v", result.Error)
+			return errors.New("删除频道禁用词失败")
+		}
+		result = tx.Delete(&EpisodeCounter{}, "channel_id = ?", id)
+		if result.Error != nil {
+			log.Printf("删除频道计数器失败：%v", result.Error)
+			return errors.New("删除频道计数器失败")
+		}
 		return nil
 	})
 	if err != nil {
@@ -70,8 +70,40 @@ func (ChannelTag) TableName() string {
 	return "channel_tag"
 }
 
+func (ChannelGroupQuota) TableName() string {
+	return "channel_group_quota"
+}
+
+func (TagUsage) TableName() string {
+	return "tag_usage"
+}
+
+func (BannedWord) TableName() string {
+	return "banned_word"
+}
+
+func (TagTranslation) TableName() string {
+	return "tag_translation"
+}
+
+func (TagRule) TableName() string {
+	return "tag_rule"
+}
+
+func (TitleHistory) TableName() string {
+	return "title_history"
+}
+
+func (EpisodeCounter) TableName() string {
+	return "episode_counter"
+}
+
 func runMigrations() error {
-	if err := DB.AutoMigrate(&Tag{}, &Channel{}, &ChannelTag{}); err != nil {
+	if err := DB.AutoMigrate(&Tag{}, &Channel{}, &ChannelTag{}, &TagGroup{}, &ChannelGroupQuota{}, &TagUsage{}, &TitleHistory{}, &TagRule{}, &BannedWord{}, &TagTranslation{}, &EpisodeCounter{}); err != nil {
+		return fmt.Errorf("数据库迁移失败：%w", err)
+	}
+	// 新增 pin_order 列之前已有的频道标签关联，置顶顺序为 NULL，统一设置为0（不置顶）
+	if err := DB.Model(&ChannelTag{}).Where("pin_order IS NULL").Update("pin_order", 0).Error; err != nil {
 		return fmt.Errorf("数据库迁移失败：%w", err)
 	}
 	return nil
@@ -0,0 +1,115 @@
+// 频道集数计数器数据操作
+package gorm
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type EpisodeCounterRepository interface {
+	// 获取频道的所有计数器
+	ListEpisodeCounters(channelId int64) ([]*EpisodeCounter, error)
+	// 获取频道中指定计数器的当前值，计数器不存在时返回0
+	GetEpisodeCounter(channelId int64, name string) (int, error)
+	// 设置计数器的值，计数器不存在时创建
+	SetEpisodeCounter(channelId int64, name string, value int) (*EpisodeCounter, error)
+}
+
+type episodeCounterRepository struct {
+	db *gorm.DB
+}
+
+func NewEpisodeCounterRepository() EpisodeCounterRepository {
+	return &episodeCounterRepository{db: DB}
+}
+
+// NormalizeCounterName 去掉计数器名称首尾的空白，名称为空或过长时返回错误
+func NormalizeCounterName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", errors.New("计数器名称不能为空")
+	}
+	if utf8.RuneCountInString(name) > MaxCounterNameLength {
+		return "", errors.New("计数器名称不能超过30个字符")
+	}
+	return name, nil
+}
+
+func (r *episodeCounterRepository) ListEpisodeCounters(channelId int64) ([]*EpisodeCounter, error) {
+	counters := make([]*EpisodeCounter, 0)
+	if err := r.db.Where("channel_id = ?", channelId).Order("name").Find(&counters).Error; err != nil {
+		log.Printf("查询频道计数器失败：%v", err)
+		return nil, errors.New("查询频道计数器失败")
+	}
+	return counters, nil
+}
+
+func (r *episodeCounterRepository) GetEpisodeCounter(channelId int64, name string) (int, error) {
+	var counters []*EpisodeCounter
+	if err := r.db.Where("channel_id = ? AND name = ?", channelId, name).Limit(1).Find(&counters).Error; err != nil {
+		log.Printf("查询频道计数器失败：%v", err)
+		return 0, errors.New("查询频道计数器失败")
+	}
+	if len(counters) == 0 {
+		return 0, nil
+	}
+	return counters[0].Value, nil
+}
+
+func (r *episodeCounterRepository) SetEpisodeCounter(channelId int64, name string, value int) (*EpisodeCounter, error) {
+	name, err := NormalizeCounterName(name)
+	if err != nil {
+		return nil, err
+	}
+	if value < 0 {
+		return nil, errors.New("计数器的值不能小于0")
+	}
+	if err := r.db.First(&Channel{}, channelId).Error; err != nil {
+		log.Printf("查询频道失败：%v", err)
+		return nil, errors.New("频道不存在")
+	}
+	counter := EpisodeCounter{ChannelId: channelId, Name: name, Value: value, UpdatedAt: time.Now()}
+	err = r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "channel_id"}, {Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"value", "updated_at"}),
+	}).Create(&counter).Error
+	if err != nil {
+		log.Printf("设置频道计数器失败：%v", err)
+		return nil, errors.New("设置频道计数器失败")
+	}
+	// 计数器已存在时 Create 不会回填ID，重新查询
+	if err := r.db.Where("channel_id = ? AND name = ?", channelId, name).First(&counter).Error; err != nil {
+		log.Printf("查询频道计数器失败：%v", err)
+		return nil, errors.New("查询频道计数器失败")
+	}
+	return &counter, nil
+}
+
+// 在事务tx中将计数器推进到已确认使用的集数episode并返回新的值：计数器取当前值与episode中较大的一个，
+// 计数器不存在时直接取episode。使用单条 INSERT ... ON CONFLICT DO UPDATE 语句完成，
+// 同一集的多个标题、乱序确认或并发确认都不会让计数器跳过或回退
+func advanceEpisodeCounter(tx *gorm.DB, channelId int64, name string, episode int) (int, error) {
+	counter := EpisodeCounter{ChannelId: channelId, Name: name, Value: episode, UpdatedAt: time.Now()}
+	err := tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "channel_id"}, {Name: "name"}},
+		DoUpdates: clause.Assignments(map[string]any{
+			"value":      gorm.Expr("MAX(episode_counter.value, ?)", episode),
+			"updated_at": counter.UpdatedAt,
+		}),
+	}).Create(&counter).Error
+	if err != nil {
+		log.Printf("更新频道计数器失败：%v", err)
+		return 0, errors.New("更新频道计数器失败")
+	}
+	if err := tx.Where("channel_id = ? AND name = ?", channelId, name).First(&counter).Error; err != nil {
+		log.Printf("查询频道计数器失败：%v", err)
+		return 0, errors.New("查询频道计数器失败")
+	}
+	return counter.Value, nil
+}
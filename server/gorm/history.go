@@ -0,0 +1,156 @@
+// 标题生成历史数据操作
+package gorm
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 分页查询的默认及最大每页数量
+const (
+	DefaultHistoryPageSize = 20
+	MaxHistoryPageSize     = 100
+)
+
+type HistoryRepository interface {
+	// 保存生成的标题
+	CreateHistory(histories []*TitleHistory) error
+	// 按条件分页查询历史标题，返回当前页数据及总数
+	ListHistory(hq *HistoryQuery) ([]*TitleHistoryResponse, int64, error)
+	// 获取频道最近生成的limit条历史标题，新的在前；usedOnly 为 true 时只获取已确认使用的标题
+	RecentHistory(channelId int64, limit int, usedOnly bool) ([]*TitleHistory, error)
+	// 确认使用历史标题，标题使用了集数计数器时将计数器推进到该标题的集数（不会回退）。返回确认后的历史标题及计数器的新值
+	UseHistory(id int) (*TitleHistory, int, error)
+}
+
+type historyRepository struct {
+	db *gorm.DB
+}
+
+func NewHistoryRepository() HistoryRepository {
+	return &historyRepository{db: DB}
+}
+
+func (r *historyRepository) CreateHistory(histories []*TitleHistory) error {
+	if len(histories) == 0 {
+		return nil
+	}
+	if err := r.db.Create(histories).Error; err != nil {
+		log.Printf("保存标题历史失败：%v", err)
+		return errors.New("保存标题历史失败")
+	}
+	return nil
+}
+
+func (r *historyRepository) ListHistory(hq *HistoryQuery) ([]*TitleHistoryResponse, int64, error) {
+	query := r.db.Model(&TitleHistory{})
+	if hq.Channel > 0 {
+		query = query.Where("channel_id = ?", hq.Channel)
+	}
+	if hq.From != "" {
+		from, err := time.ParseInLocation("2006-01-02", hq.From, time.Local)
+		if err != nil {
+			return nil, 0, errors.New("开始日期格式错误，应为 YYYY-MM-DD")
+		}
+		query = query.Where("created_at >= ?", from)
+	}
+	if hq.To != "" {
+		to, err := time.ParseInLocation("2006-01-02", hq.To, time.Local)
+		if err != nil {
+			return nil, 0, errors.New("结束日期格式错误，应为 YYYY-MM-DD")
+		}
+		// 结束日期包含当天
+		query = query.Where("created_at < ?", to.AddDate(0, 0, 1))
+	}
+	if keyword := strings.TrimSpace(hq.Keyword); keyword != "" {
+		// 转义LIKE通配符，按字面量搜索
+		escaped := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(keyword)
+		pattern := "%" + escaped + "%"
+		query = query.Where(`title LIKE ? ESCAPE '\' OR theme LIKE ? ESCAPE '\'`, pattern, pattern)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		log.Printf("统计标题历史失败：%v", err)
+		return nil, 0, errors.New("查询标题历史失败")
+	}
+	var histories []*TitleHistory
+	offset := (hq.Page - 1) * hq.PageSize
+	if err := query.Order("id DESC").Offset(offset).Limit(hq.PageSize).Find(&histories).Error; err != nil {
+		log.Printf("查询标题历史失败：%v", err)
+		return nil, 0, errors.New("查询标题历史失败")
+	}
+
+	historyResponses := make([]*TitleHistoryResponse, 0, len(histories))
+	for _, history := range histories {
+		tags := make([]string, 0)
+		if history.Tags != "" {
+			tags = strings.Split(history.Tags, ",")
+		}
+		historyResponses = append(historyResponses, &TitleHistoryResponse{
+			Id:        history.Id,
+			ChannelId: history.ChannelId,
+			Theme:     history.Theme,
+			Tags:      tags,
+			Title:     history.Title,
+			Seed:      history.Seed,
+			Counter:   history.Counter,
+			Episode:   history.Episode,
+			UsedAt:    history.UsedAt,
+			CreatedAt: history.CreatedAt,
+		})
+	}
+	return historyResponses, total, nil
+}
+
+func (r *historyRepository) RecentHistory(channelId int64, limit int, usedOnly bool) ([]*TitleHistory, error) {
+	var histories []*TitleHistory
+	query := r.db.Where("channel_id = ?", channelId)
+	if usedOnly {
+		query = query.Where("used_at IS NOT NULL")
+	}
+	if err := query.Order("id DESC").Limit(limit).Find(&histories).Error; err != nil {
+		log.Printf("查询频道历史标题失败：%v", err)
+		return nil, errors.New("查询频道历史标题失败")
+	}
+	return histories, nil
+}
+
+func (r *historyRepository) UseHistory(id int) (*TitleHistory, int, error) {
+	var history TitleHistory
+	counterValue := 0
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&history, id).Error; err != nil {
+			log.Printf("查询标题历史失败：%v", err)
+			return errors.New("历史标题不存在")
+		}
+		// 只更新尚未确认的记录，重复确认不会让计数器多次增加
+		now := time.Now()
+		result := tx.Model(&TitleHistory{}).Where("id = ? AND used_at IS NULL", id).Update("used_at", now)
+		if result.Error != nil {
+			log.Printf("确认使用标题失败：%v", result.Error)
+			return errors.New("确认使用标题失败")
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("该标题已确认使用")
+		}
+		history.UsedAt = &now
+		if history.Counter == "" {
+			return nil
+		}
+		value, err := advanceEpisodeCounter(tx, history.ChannelId, history.Counter, history.Episode)
+		if err != nil {
+			return err
+		}
+		counterValue = value
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return &history, counterValue, nil
+}
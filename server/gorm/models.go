@@ -1,16 +1,109 @@
 package gorm
 
+import "time"
+
 // 标签模型
 type Tag struct {
+	Id        int64  `json:"id"`
+	Name      string `json:"name"`
+	GroupId   int64  `json:"group_id"`  // 所属标签分组，0表示未分组
+	Triggers  string `json:"triggers"`  // 触发词，每行一个，主题命中时优先使用该标签
+	Exclusive bool   `json:"exclusive"` // 独占标签：只有主题命中触发词时才会使用
+}
+
+// 标签译名模型：同一个标签在不同语言频道中使用的名称
+type TagTranslation struct {
+	Id     int64  `json:"id"`
+	TagId  int64  `json:"tag_id" gorm:"uniqueIndex:idx_tag_translation_locale"`
+	Locale string `json:"locale" gorm:"uniqueIndex:idx_tag_translation_locale"` // BCP 47 语言标签，如 zh-CN、en
+	Name   string `json:"name"`
+}
+
+// 标签分组模型，如“游戏名”、“语言”、“类型”
+type TagGroup struct {
 	Id   int64  `json:"id"`
-	Name string `json:"name"`
+	Name string `json:"name" gorm:"uniqueIndex"`
 }
 
 // 频道模型
 type Channel struct {
-	Id           int64  `json:"id"`
-	Name         string `json:"name"`
-	DefaultTitle string `json:"default_title"`
+	Id            int64  `json:"id"`
+	Name          string `json:"name"`
+	DefaultTitle  string `json:"default_title"`
+	TitleTemplate string `json:"title_template"` // 标题模板，支持 {{.Theme}}、{{.Episode}}、{{.Date}}、{{.Hashtags}} 占位符
+	LengthMetric  string `json:"length_metric"`  // 标题长度计算方式：runes、utf16、graphemes、width，为空时使用runes
+	RecentWindow  int    `json:"recent_window"`  // 回避最近几次生成中使用过的标签（按使用次数降低权重），0表示不回避
+	TitleStrategy string `json:"title_strategy"` // 标签选择策略：weighted、random、round_robin、greedy，为空时使用weighted
+	Locale        string `json:"locale"`         // 频道语言（BCP 47），生成标题时使用标签在该语言下的译名，为空时使用标签原名
+	// 标题装饰
+	TitlePrefix      string `json:"title_prefix"`      // 标题前缀，如“【Live】”
+	TitleSuffix      string `json:"title_suffix"`      // 标题后缀
+	HashtagSeparator string `json:"hashtag_separator"` // 话题标签之间的分隔符，为空时使用一个空格
+	HashtagPlacement string `json:"hashtag_placement"` // 话题标签位置：end、start、after_first_clause，为空时使用end
+	HashtagCase      string `json:"hashtag_case"`      // 话题标签大小写：as_is、lower、camel，为空时使用lower
+	// 长度限制，按 LengthMetric 计算，0表示使用默认值
+	MaxTitleLength int `json:"max_title_length"` // 标题最大长度，默认为100
+	MaxThemeLength int `json:"max_theme_length"` // 主题最大长度，默认与标题最大长度相同
+	MaxHashtags    int `json:"max_hashtags"`     // 话题标签最大数量，默认不限制
+}
+
+// 频道近期标签使用记录：每次生成标题记录一行，用于避免连续的视频使用几乎相同的标签
+type TagUsage struct {
+	Id        int64     `json:"id"`
+	ChannelId int64     `json:"channel_id" gorm:"index"`
+	TagIds    string    `json:"tag_ids"` // 本次使用的标签ID，逗号分隔
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// 标题生成历史模型
+type TitleHistory struct {
+	Id        int64      `json:"id"`
+	ChannelId int64      `json:"channel_id" gorm:"index"`
+	Theme     string     `json:"theme"`
+	Tags      string     `json:"tags"` // 选中的标签名，逗号分隔
+	Title     string     `json:"title"`
+	Seed      int64      `json:"seed"`
+	Counter   string     `json:"counter"` // 生成时使用的集数计数器名称，为空表示未使用计数器
+	Episode   int        `json:"episode"` // 生成时使用的集数
+	UsedAt    *time.Time `json:"used_at"` // 确认使用的时间，为空表示未确认
+	CreatedAt time.Time  `json:"created_at" gorm:"index"`
+}
+
+// 频道集数计数器模型：同一频道可以有多个按名称区分的计数器（如不同的系列），Value 为已确认使用的最新集数
+type EpisodeCounter struct {
+	Id        int64     `json:"id"`
+	ChannelId int64     `json:"channel_id" gorm:"uniqueIndex:idx_episode_counter_channel_name"`
+	Name      string    `json:"name" gorm:"uniqueIndex:idx_episode_counter_channel_name"`
+	Value     int       `json:"value"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// 集数计数器名称的最大长度
+const MaxCounterNameLength = 30
+
+// 近期标签回避最多参考的生成次数，同时也是每个频道保留的使用记录数
+const MaxRecentWindow = 50
+
+// 标签权重的默认值与上限
+const (
+	DefaultTagWeight = 1
+	MaxTagWeight     = 100
+)
+
+// 标签兼容规则模型，如“#minecraft 依赖 #mc”、“#shorts 与 #fullgame 互斥”
+type TagRule struct {
+	Id       int64  `json:"id"`
+	TagId    int64  `json:"tag_id" gorm:"index"`
+	Kind     string `json:"kind"` // requires（使用标签时必须同时使用目标标签）或 excludes（两者不能同时使用，双向生效）
+	TargetId int64  `json:"target_id" gorm:"index"`
+}
+
+// 禁用词模型：生成标题时检查主题及标签，ChannelId 为0表示对所有频道生效
+type BannedWord struct {
+	Id        int64  `json:"id"`
+	Word      string `json:"word" gorm:"uniqueIndex:idx_banned_word_channel"` // NFKC 规范化并转换为小写后的禁用词
+	ChannelId int64  `json:"channel_id" gorm:"uniqueIndex:idx_banned_word_channel"`
+	Action    string `json:"action"` // reject（拒绝生成）或 mask（屏蔽主题中的禁用词）
 }
 
 // 频道-标签关联模型
@@ -18,40 +111,171 @@ type ChannelTag struct {
 	Id        int64 `json:"id"`
 	ChannelId int64 `json:"channel_id"`
 	TagId     int64 `json:"tag_id"`
+	Weight    int   `json:"weight" gorm:"default:1"`    // 标签在该频道中被选中的权重，权重越高越容易被选中
+	PinOrder  int   `json:"pin_order" gorm:"default:0"` // 置顶顺序，从1开始，0表示不置顶。置顶标签总是排在最前且不会因长度被舍弃
+}
+
+// 频道-标签分组配额模型：生成标题时，该分组的标签数量需在 [Min, Max] 之间
+type ChannelGroupQuota struct {
+	Id        int64 `json:"id"`
+	ChannelId int64 `json:"channel_id"`
+	GroupId   int64 `json:"group_id"`
+	Min       int   `json:"min"`
+	Max       int   `json:"max"` // 0表示不限制
+}
+
+// 标签分组配额，用于频道请求与响应
+type GroupQuota struct {
+	GroupId int64 `json:"group_id" form:"group_id"`
+	Min     int   `json:"min" form:"min"`
+	Max     int   `json:"max" form:"max"` // 0表示不限制
 }
 
 // 创建标签请求
 type TagCreateRequest struct {
+	Name         string            `json:"name" form:"name" binding:"required"`
+	Channels     []int64           `json:"channels" form:"channels" binding:"required"`
+	Weight       int               `json:"weight" form:"weight"`             // 标签在所有关联频道中的权重，为0时使用默认权重
+	GroupId      int64             `json:"group_id" form:"group_id"`         // 所属标签分组，0表示未分组
+	Triggers     []string          `json:"triggers" form:"triggers"`         // 触发词，写作 /pattern/ 时作为正则表达式，均不区分大小写
+	Exclusive    bool              `json:"exclusive" form:"exclusive"`       // 独占标签：只有主题命中触发词时才会使用，需要设置触发词
+	Translations map[string]string `json:"translations" form:"translations"` // 语言标签 -> 译名，译名按标签名规则规范化
+}
+
+// 修改标签请求：重命名标签，并替换标签关联的全部频道
+type TagUpdateRequest struct {
 	Name     string  `json:"name" form:"name" binding:"required"`
 	Channels []int64 `json:"channels" form:"channels" binding:"required"`
+	Weight   int     `json:"weight" form:"weight"` // 新关联频道中的权重，为0时使用默认权重；仍然关联的频道保留原有的权重及置顶顺序
+	// 以下字段为空时保持原值
+	GroupId   *int64   `json:"group_id" form:"group_id"`   // 所属标签分组，0表示移出分组
+	Triggers  []string `json:"triggers" form:"triggers"`   // 触发词，传空数组表示清除
+	Exclusive *bool    `json:"exclusive" form:"exclusive"` // 独占标签，需要设置触发词
+}
+
+// 修改标签译名请求，会替换标签已有的全部译名
+type TagTranslationsRequest struct {
+	Translations map[string]string `json:"translations" form:"translations"`
 }
 
 // 标签列表响应体
 type TagResponse struct {
-	Id       int64   `json:"id"`
-	Name     string  `json:"name"`
-	Channels []int64 `json:"channels"`
+	Id           int64             `json:"id"`
+	Name         string            `json:"name"`
+	Channels     []int64           `json:"channels"`
+	GroupId      int64             `json:"group_id"`
+	Triggers     []string          `json:"triggers"`
+	Exclusive    bool              `json:"exclusive"`
+	Translations map[string]string `json:"translations"` // 语言标签 -> 译名
+}
+
+// 创建、修改标签兼容规则请求
+type TagRuleRequest struct {
+	TagId    int64  `json:"tag_id" form:"tag_id" binding:"required"`
+	Kind     string `json:"kind" form:"kind" binding:"required"` // requires 或 excludes
+	TargetId int64  `json:"target_id" form:"target_id" binding:"required"`
+}
+
+// 标签兼容规则响应体
+type TagRuleResponse struct {
+	Id         int64  `json:"id"`
+	TagId      int64  `json:"tag_id"`
+	TagName    string `json:"tag_name"`
+	Kind       string `json:"kind"`
+	TargetId   int64  `json:"target_id"`
+	TargetName string `json:"target_name"`
+}
+
+// 创建禁用词请求
+type BannedWordCreateRequest struct {
+	Word      string `json:"word" form:"word" binding:"required"`
+	ChannelId int64  `json:"channel_id" form:"channel_id"` // 0表示对所有频道生效
+	Action    string `json:"action" form:"action"`         // reject 或 mask，为空时使用 reject
+}
+
+// 创建标签分组请求
+type TagGroupCreateRequest struct {
+	Name string `json:"name" form:"name" binding:"required"`
 }
 
 // 创建频道请求
 type ChannelCreateRequest struct {
-	Name         string  `json:"name" form:"name" binding:"required"`
-	Tags         []int64 `json:"tags" form:"tags"`
-	DefaultTitle string  `json:"default_title"`
+	Name             string        `json:"name" form:"name" binding:"required"`
+	Tags             []int64       `json:"tags" form:"tags"`
+	TagWeights       map[int64]int `json:"tag_weights" form:"tag_weights"` // 标签ID -> 权重，未设置的标签使用默认权重
+	DefaultTitle     string        `json:"default_title" form:"default_title"`
+	TitleTemplate    string        `json:"title_template" form:"title_template"`
+	GroupQuotas      []GroupQuota  `json:"group_quotas" form:"group_quotas"`
+	PinnedTags       []int64       `json:"pinned_tags" form:"pinned_tags"` // 置顶标签ID，按置顶顺序排列，必须是频道关联的标签
+	LengthMetric     string        `json:"length_metric" form:"length_metric"`
+	RecentWindow     int           `json:"recent_window" form:"recent_window"`
+	TitleStrategy    string        `json:"title_strategy" form:"title_strategy"`
+	Locale           string        `json:"locale" form:"locale"`
+	TitlePrefix      string        `json:"title_prefix" form:"title_prefix"`
+	TitleSuffix      string        `json:"title_suffix" form:"title_suffix"`
+	HashtagSeparator string        `json:"hashtag_separator" form:"hashtag_separator"`
+	HashtagPlacement string        `json:"hashtag_placement" form:"hashtag_placement"`
+	HashtagCase      string        `json:"hashtag_case" form:"hashtag_case"`
+	MaxTitleLength   int           `json:"max_title_length" form:"max_title_length"`
+	MaxThemeLength   int           `json:"max_theme_length" form:"max_theme_length"`
+	MaxHashtags      int           `json:"max_hashtags" form:"max_hashtags"`
 }
 
-// 更新频道请求
+// 更新频道请求，除ID外与新增频道请求相同
 type ChannelUpdateRequest struct {
-	Id           int64   `json:"id" form:"id" binding:"required"`
-	Name         string  `json:"name" form:"name" binding:"required"`
-	Tags         []int64 `json:"tags" form:"tags"`
-	DefaultTitle string  `json:"default_title" form:"default_title"`
+	Id int64 `json:"id" form:"id" binding:"required"`
+	ChannelCreateRequest
 }
 
 // 获取频道响应
 type ChannelResponse struct {
-	Id           int64   `json:"id"`
-	Name         string  `json:"name"`
-	Tags         []int64 `json:"tags"`
-	DefaultTitle string  `json:"default_title"`
+	Id               int64         `json:"id"`
+	Name             string        `json:"name"`
+	Tags             []int64       `json:"tags"`
+	TagWeights       map[int64]int `json:"tag_weights"` // 标签ID -> 权重
+	DefaultTitle     string        `json:"default_title"`
+	TitleTemplate    string        `json:"title_template"`
+	GroupQuotas      []GroupQuota  `json:"group_quotas"`
+	PinnedTags       []int64       `json:"pinned_tags"` // 置顶标签ID，按置顶顺序排列
+	LengthMetric     string        `json:"length_metric"`
+	RecentWindow     int           `json:"recent_window"`
+	TitleStrategy    string        `json:"title_strategy"`
+	Locale           string        `json:"locale"`
+	TitlePrefix      string        `json:"title_prefix"`
+	TitleSuffix      string        `json:"title_suffix"`
+	HashtagSeparator string        `json:"hashtag_separator"`
+	HashtagPlacement string        `json:"hashtag_placement"`
+	HashtagCase      string        `json:"hashtag_case"`
+	MaxTitleLength   int           `json:"max_title_length"`
+	MaxThemeLength   int           `json:"max_theme_length"`
+	MaxHashtags      int           `json:"max_hashtags"`
+}
+
+// 标题历史查询请求
+type HistoryQuery struct {
+	Channel  int64  `form:"channel"`
+	From     string `form:"from"` // 开始日期，格式为 YYYY-MM-DD
+	To       string `form:"to"`   // 结束日期（包含当天），格式为 YYYY-MM-DD
+	Keyword  string `form:"q"`    // 在标题和主题中搜索的文本
+	Page     int    `form:"page"`
+	PageSize int    `form:"page_size"`
+}
+
+// 标题历史响应体
+type TitleHistoryResponse struct {
+	Id        int64      `json:"id"`
+	ChannelId int64      `json:"channel_id"`
+	Theme     string     `json:"theme"`
+	Tags      []string   `json:"tags"`
+	Title     string     `json:"title"`
+	Seed      int64      `json:"seed"`
+	Counter   string     `json:"counter"`
+	Episode   int        `json:"episode"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// 设置集数计数器请求
+type EpisodeCounterRequest struct {
+	Value *int `json:"value" form:"value" binding:"required"`
 }
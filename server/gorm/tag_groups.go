@@ -0,0 +1,72 @@
+// 标签分组数据操作
+package gorm
+
+import (
+	"errors"
+	"log"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+type TagGroupRepository interface {
+	// 获取所有标签分组
+	ListTagGroups() ([]*TagGroup, error)
+	// 创建标签分组
+	CreateTagGroup(tgcr *TagGroupCreateRequest) error
+	// 删除标签分组
+	DeleteTagGroup(id int) error
+}
+
+type tagGroupRepository struct {
+	db *gorm.DB
+}
+
+func NewTagGroupRepository() TagGroupRepository {
+	return &tagGroupRepository{db: DB}
+}
+
+func (r *tagGroupRepository) ListTagGroups() ([]*TagGroup, error) {
+	var groups []*TagGroup
+	if err := r.db.Order("id").Find(&groups).Error; err != nil {
+		log.Printf("查询标签分组失败：%v", err)
+		return nil, errors.New("查询标签分组失败")
+	}
+	return groups, nil
+}
+
+func (r *tagGroupRepository) CreateTagGroup(tgcr *TagGroupCreateRequest) error {
+	group := TagGroup{Name: tgcr.Name}
+	if err := r.db.Create(&group).Error; err != nil {
+		log.Printf("创建标签分组失败：%v", err)
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return errors.New("标签分组名称已存在")
+		}
+		return errors.New("创建标签分组失败")
+	}
+	return nil
+}
+
+func (r *tagGroupRepository) DeleteTagGroup(id int) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&TagGroup{}, id).Error; err != nil {
+			log.Printf("删除标签分组失败：%v", err)
+			return errors.New("删除标签分组失败")
+		}
+		// 分组下的标签变为未分组
+		if err := tx.Model(&Tag{}).Where("group_id = ?", id).Update("group_id", 0).Error; err != nil {
+			log.Printf("重置标签分组失败：%v", err)
+			return errors.New("重置标签分组失败")
+		}
+		if err := tx.Delete(&ChannelGroupQuota{}, "group_id = ?", id).Error; err != nil {
+			log.Printf("删除频道分组配额失败：%v", err)
+			return errors.New("删除频道分组配额失败")
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("删除标签分组时，开启事务失败：%v", err.Error())
+		return errors.New("删除标签分组失败")
+	}
+	return nil
+}
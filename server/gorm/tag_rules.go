@@ -0,0 +1,137 @@
+// 标签兼容规则数据操作
+package gorm
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"fswrhzl/ytb_title/server/services"
+
+	"gorm.io/gorm"
+)
+
+type TagRuleRepository interface {
+	// 获取所有标签兼容规则
+	ListTagRules() ([]*TagRuleResponse, error)
+	// 创建标签兼容规则
+	CreateTagRule(trr *TagRuleRequest) error
+	// 修改标签兼容规则
+	UpdateTagRule(id int, trr *TagRuleRequest) error
+	// 删除标签兼容规则
+	DeleteTagRule(id int) error
+}
+
+type tagRuleRepository struct {
+	db *gorm.DB
+}
+
+func NewTagRuleRepository() TagRuleRepository {
+	return &tagRuleRepository{db: DB}
+}
+
+func (r *tagRuleRepository) ListTagRules() ([]*TagRuleResponse, error) {
+	var rules []*TagRuleResponse
+	err := r.db.Table("tag_rule AS r").
+		Select("r.id, r.tag_id, t.name AS tag_name, r.kind, r.target_id, g.name AS target_name").
+		Joins("JOIN tags AS t ON t.id = r.tag_id").
+		Joins("JOIN tags AS g ON g.id = r.target_id").
+		Order("r.id").
+		Scan(&rules).Error
+	if err != nil {
+		log.Printf("查询标签兼容规则失败：%v", err)
+		return nil, errors.New("查询标签兼容规则失败")
+	}
+	return rules, nil
+}
+
+func (r *tagRuleRepository) CreateTagRule(trr *TagRuleRequest) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		rule := TagRule{TagId: trr.TagId, Kind: trr.Kind, TargetId: trr.TargetId}
+		if err := checkTagRule(tx, &rule); err != nil {
+			return err
+		}
+		if err := tx.Create(&rule).Error; err != nil {
+			log.Printf("创建标签兼容规则失败：%v", err)
+			return errors.New("创建标签兼容规则失败")
+		}
+		return nil
+	})
+}
+
+func (r *tagRuleRepository) UpdateTagRule(id int, trr *TagRuleRequest) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var rule TagRule
+		if err := tx.First(&rule, id).Error; err != nil {
+			log.Printf("查询标签兼容规则失败：%v", err)
+			return errors.New("标签兼容规则不存在")
+		}
+		rule.TagId, rule.Kind, rule.TargetId = trr.TagId, trr.Kind, trr.TargetId
+		if err := checkTagRule(tx, &rule); err != nil {
+			return err
+		}
+		if err := tx.Save(&rule).Error; err != nil {
+			log.Printf("修改标签兼容规则失败：%v", err)
+			return errors.New("修改标签兼容规则失败")
+		}
+		return nil
+	})
+}
+
+func (r *tagRuleRepository) DeleteTagRule(id int) error {
+	if err := r.db.Delete(&TagRule{}, id).Error; err != nil {
+		log.Printf("删除标签兼容规则失败：%v", err)
+		return errors.New("删除标签兼容规则失败")
+	}
+	return nil
+}
+
+// 校验即将保存的规则：标签必须存在、规则不能重复，且与已有规则合并后不能出现依赖循环或矛盾
+func checkTagRule(tx *gorm.DB, rule *TagRule) error {
+	if rule.Kind != services.TagRuleRequires && rule.Kind != services.TagRuleExcludes {
+		return fmt.Errorf("规则类型只能是%s或%s", services.TagRuleRequires, services.TagRuleExcludes)
+	}
+	if rule.TagId == rule.TargetId {
+		return errors.New("规则的标签与目标标签不能相同")
+	}
+	var tags []*Tag
+	if err := tx.Find(&tags).Error; err != nil {
+		log.Printf("查询标签失败：%v", err)
+		return errors.New("查询标签失败")
+	}
+	names := make(map[int64]string, len(tags))
+	for _, tag := range tags {
+		names[tag.Id] = tag.Name
+	}
+	if _, ok := names[rule.TagId]; !ok {
+		return errors.New("标签不存在")
+	}
+	if _, ok := names[rule.TargetId]; !ok {
+		return errors.New("目标标签不存在")
+	}
+	var rules []*TagRule
+	if err := tx.Where("id <> ?", rule.Id).Find(&rules).Error; err != nil {
+		log.Printf("查询标签兼容规则失败：%v", err)
+		return errors.New("查询标签兼容规则失败")
+	}
+	for _, existing := range rules {
+		same := existing.Kind == rule.Kind && existing.TagId == rule.TagId && existing.TargetId == rule.TargetId
+		// 互斥规则双向生效，A 与 B 互斥等同于 B 与 A 互斥
+		reversed := rule.Kind == services.TagRuleExcludes && existing.Kind == services.TagRuleExcludes &&
+			existing.TagId == rule.TargetId && existing.TargetId == rule.TagId
+		if same || reversed {
+			return errors.New("规则已存在")
+		}
+	}
+	checked := make([]services.TagRule, 0, len(rules)+1)
+	for _, r := range append(rules, rule) {
+		checked = append(checked, services.TagRule{
+			TagId:      r.TagId,
+			TagName:    names[r.TagId],
+			Kind:       r.Kind,
+			TargetId:   r.TargetId,
+			TargetName: names[r.TargetId],
+		})
+	}
+	return services.CheckTagRules(checked)
+}
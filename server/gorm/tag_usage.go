@@ -0,0 +1,72 @@
+// 频道近期标签使用记录数据操作
+package gorm
+
+import (
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type TagUsageRepository interface {
+	// 获取频道最近limit次生成中各标签的使用次数，键为标签ID
+	RecentTagUsage(channelId int64, limit int) (map[int64]int, error)
+	// 记录一次生成所使用的标签（每次为频道生成标题时记录一次，不等待确认使用），并只保留频道最近MaxRecentWindow次的记录
+	RecordTagUsage(channelId int64, tagIds []int64) error
+}
+
+type tagUsageRepository struct {
+	db *gorm.DB
+}
+
+func NewTagUsageRepository() TagUsageRepository {
+	return &tagUsageRepository{db: DB}
+}
+
+func (r *tagUsageRepository) RecentTagUsage(channelId int64, limit int) (map[int64]int, error) {
+	var usages []*TagUsage
+	if err := r.db.Where("channel_id = ?", channelId).Order("id DESC").Limit(limit).Find(&usages).Error; err != nil {
+		log.Printf("查询频道近期标签失败：%v", err)
+		return nil, errors.New("查询频道近期标签失败")
+	}
+	counts := make(map[int64]int)
+	for _, usage := range usages {
+		for tagId := range strings.SplitSeq(usage.TagIds, ",") {
+			tagIdInt, err := strconv.Atoi(tagId)
+			if err != nil {
+				continue
+			}
+			counts[int64(tagIdInt)]++
+		}
+	}
+	return counts, nil
+}
+
+func (r *tagUsageRepository) RecordTagUsage(channelId int64, tagIds []int64) error {
+	ids := make([]string, 0, len(tagIds))
+	for _, tagId := range tagIds {
+		ids = append(ids, strconv.FormatInt(tagId, 10))
+	}
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		usage := TagUsage{ChannelId: channelId, TagIds: strings.Join(ids, ","), CreatedAt: time.Now()}
+		if err := tx.Create(&usage).Error; err != nil {
+			log.Printf("记录频道标签使用失败：%v", err)
+			return errors.New("记录频道标签使用失败")
+		}
+		// 删除超出保留数量的旧记录
+		keep := tx.Model(&TagUsage{}).Select("id").Where("channel_id = ?", channelId).Order("id DESC").Limit(MaxRecentWindow)
+		if err := tx.Where("channel_id = ? AND id NOT IN (?)", channelId, keep).Delete(&TagUsage{}).Error; err != nil {
+			log.Printf("清理频道标签使用记录失败：%v", err)
+			return errors.New("清理频道标签使用记录失败")
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("记录频道标签使用时，开启事务失败：%v", err.Error())
+		return err
+	}
+	return nil
+}
@@ -6,14 +6,21 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"slices"
 	"strconv"
 	"strings"
 
+	"fswrhzl/ytb_title/server/services"
+
 	"gorm.io/gorm"
 )
 
 type TagRepository interface {
 	CreateTag(tcr *TagCreateRequest) error
+	// 重命名标签并替换标签关联的频道
+	UpdateTag(id int, tur *TagUpdateRequest) error
+	// 替换标签的全部译名
+	SetTagTranslations(id int, translations map[string]string) error
 	DeleteTag(id int) error
 	ListTags() ([]*TagResponse, error)
 }
@@ -27,11 +34,39 @@ func NewTagRepository() TagRepository {
 }
 
 func (tr *tagRepository) CreateTag(tcr *TagCreateRequest) error {
+	// 按 YouTube 话题标签规则规范化标签名
+	name, err := services.NormalizeHashtag(tcr.Name)
+	if err != nil {
+		return err
+	}
+	triggers, err := services.NormalizeTriggers(tcr.Triggers)
+	if err != nil {
+		return err
+	}
+	if tcr.Exclusive && len(triggers) == 0 {
+		return services.ValidationErrors{{Field: "exclusive", Message: "独占标签必须设置触发词"}}
+	}
+	translations, err := normalizeTranslations(tcr.Translations)
+	if err != nil {
+		return err
+	}
 	// 新增标签
 	var tag Tag = Tag{
-		Name: tcr.Name,
+		Name:      name,
+		GroupId:   tcr.GroupId,
+		Triggers:  strings.Join(triggers, "\n"),
+		Exclusive: tcr.Exclusive,
 	}
-	err := tr.db.Transaction(func(tx *gorm.DB) error {
+	err = tr.db.Transaction(func(tx *gorm.DB) error {
+		if tcr.GroupId != 0 {
+			if err := tx.First(&TagGroup{}, tcr.GroupId).Error; err != nil {
+				log.Printf("查询标签分组失败: %v", err)
+				return errors.New("标签分组不存在")
+			}
+		}
+		if err := checkTagNameUnique(tx, name, 0); err != nil {
+			return err
+		}
 		if err := tx.Create(&tag).Error; err != nil {
 			log.Printf("创建标签失败: %v", err)
 			if strings.Contains(err.Error(), "UNIQUE constraint failed") {
@@ -40,11 +75,22 @@ func (tr *tagRepository) CreateTag(tcr *TagCreateRequest) error {
 			return errors.New("创建标签失败")
 		}
 		fmt.Printf("创建标签成功: %v", tag)
-		// 新增标签与频道的关联关系
-		for _, channelId := range tcr.Channels {
+		if err := createTranslations(tx, tag.Id, translations); err != nil {
+			return err
+		}
+		if err := checkLocalizedNamesUnique(tx, tag.Id); err != nil {
+			return err
+		}
+		// 新增标签与频道的关联关系，与修改标签相同，重复的频道只关联一次，频道必须存在
+		for _, channelId := range slices.Compact(slices.Sorted(slices.Values(tcr.Channels))) {
+			if err := tx.First(&Channel{}, channelId).Error; err != nil {
+				log.Printf("查询频道失败: %v", err)
+				return fmt.Errorf("频道（ID：%d）不存在", channelId)
+			}
 			ctLink := ChannelTag{
 				ChannelId: channelId,
 				TagId:     tag.Id,
+				Weight:    tcr.Weight,
 			}
 			if err := tx.Create(&ctLink).Error; err != nil {
 				log.Printf("为标签设置关联频道失败: %v", err)
@@ -61,6 +107,100 @@ func (tr *tagRepository) CreateTag(tcr *TagCreateRequest) error {
 	return nil
 }
 
+func (tr *tagRepository) UpdateTag(id int, tur *TagUpdateRequest) error {
+	// 与创建标签相同，按 YouTube 话题标签规则规范化标签名
+	name, err := services.NormalizeHashtag(tur.Name)
+	if err != nil {
+		return err
+	}
+	var triggers []string
+	if tur.Triggers != nil {
+		if triggers, err = services.NormalizeTriggers(tur.Triggers); err != nil {
+			return err
+		}
+	}
+	return tr.db.Transaction(func(tx *gorm.DB) error {
+		var tag Tag
+		if err := tx.First(&tag, id).Error; err != nil {
+			log.Printf("查询标签失败: %v", err)
+			return errors.New("标签不存在")
+		}
+		if err := checkTagNameUnique(tx, name, tag.Id); err != nil {
+			return err
+		}
+		updates := map[string]any{"name": name}
+		if tur.GroupId != nil {
+			if *tur.GroupId != 0 {
+				if err := tx.First(&TagGroup{}, *tur.GroupId).Error; err != nil {
+					log.Printf("查询标签分组失败: %v", err)
+					return errors.New("标签分组不存在")
+				}
+			}
+			updates["group_id"] = *tur.GroupId
+		}
+		if tur.Triggers != nil {
+			tag.Triggers = strings.Join(triggers, "\n")
+			updates["triggers"] = tag.Triggers
+		}
+		if tur.Exclusive != nil {
+			tag.Exclusive = *tur.Exclusive
+			updates["exclusive"] = tag.Exclusive
+		}
+		// 与创建标签相同，独占标签必须设置触发词（按修改后的值检查）
+		if tag.Exclusive && tag.Triggers == "" {
+			return services.ValidationErrors{{Field: "exclusive", Message: "独占标签必须设置触发词"}}
+		}
+		// 置顶标签总是放入标题，不能设为独占标签
+		if tag.Exclusive {
+			var pinned int64
+			if err := tx.Model(&ChannelTag{}).Where("tag_id = ? AND pin_order > 0", tag.Id).Count(&pinned).Error; err != nil {
+				log.Printf("查询标签置顶频道失败: %v", err)
+				return errors.New("修改标签失败")
+			}
+			if pinned > 0 {
+				return services.ValidationErrors{{Field: "exclusive", Message: "该标签是频道的置顶标签，不能设为独占标签"}}
+			}
+		}
+		if err := tx.Model(&tag).Updates(updates).Error; err != nil {
+			log.Printf("修改标签失败: %v", err)
+			if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+				return errors.New("标签名已存在")
+			}
+			return errors.New("修改标签失败")
+		}
+		if err := checkLocalizedNamesUnique(tx, tag.Id); err != nil {
+			return err
+		}
+		// 仍然关联的频道保留原有的权重及置顶顺序
+		var links []*ChannelTag
+		if err := tx.Where("tag_id = ?", tag.Id).Find(&links).Error; err != nil {
+			log.Printf("查询标签关联频道失败: %v", err)
+			return errors.New("修改标签失败")
+		}
+		if err := tx.Delete(&ChannelTag{}, "tag_id = ?", tag.Id).Error; err != nil {
+			log.Printf("删除标签与频道关联关系失败: %v", err)
+			return errors.New("删除标签与频道关联关系失败")
+		}
+		for _, channelId := range slices.Compact(slices.Sorted(slices.Values(tur.Channels))) {
+			if err := tx.First(&Channel{}, channelId).Error; err != nil {
+				log.Printf("查询频道失败: %v", err)
+				return fmt.Errorf("频道（ID：%d）不存在", channelId)
+			}
+			ctLink := ChannelTag{ChannelId: channelId, TagId: tag.Id, Weight: tur.Weight}
+			for _, link := range links {
+				if link.ChannelId == channelId {
+					ctLink.Weight, ctLink.PinOrder = link.Weight, link.PinOrder
+				}
+			}
+			if err := tx.Create(&ctLink).Error; err != nil {
+				log.Printf("为标签设置关联频道失败: %v", err)
+				return errors.New("为标签设置关联频道失败")
+			}
+		}
+		return nil
+	})
+}
+
 func (tr *tagRepository) DeleteTag(id int) error {
 	err := tr.db.Transaction(func(tx *gorm.DB) error {
 		err := tx.Delete(&Tag{}, id).Error
@@ -74,6 +214,18 @@ func (tr *tagRepository) DeleteTag(id int) error {
 			log.Printf("删除标签与频道关联关系失败: %v", err)
 			return errors.New("删除标签与频道关联关系失败")
 		}
+
+		err = tx.Delete(&TagTranslation{}, "tag_id = ?", id).Error
+		if err != nil {
+			log.Printf("删除标签译名失败: %v", err)
+			return errors.New("删除标签译名失败")
+		}
+
+		err = tx.Delete(&TagRule{}, "tag_id = ? OR target_id = ?", id, id).Error
+		if err != nil {
+			log.Printf("删除标签兼容规则失败: %v", err)
+			return errors.New("删除标签兼容规则失败")
+		}
 		return nil
 	})
 	if err != nil {
@@ -85,7 +237,7 @@ func (tr *tagRepository) DeleteTag(id int) error {
 
 func (tr *tagRepository) ListTags() ([]*TagResponse, error) {
 	rows, err := tr.db.Table("tags as t").
-		Select("t.id, t.name, GROUP_CONCAT(c.channel_id, ',') AS tlink").
+		Select("t.id, t.name, t.group_id, t.triggers, t.exclusive, GROUP_CONCAT(c.channel_id, ',') AS tlink").
 		Joins("LEFT JOIN channel_tag AS c ON t.id = c.tag_id").
 		Group("t.id").
 		Rows()
@@ -95,6 +247,19 @@ func (tr *tagRepository) ListTags() ([]*TagResponse, error) {
 	}
 	defer rows.Close()
 
+	var translations []*TagTranslation
+	if err := tr.db.Order("locale").Find(&translations).Error; err != nil {
+		log.Printf("查询标签译名失败: %v", err)
+		return nil, errors.New("查询标签失败")
+	}
+	translationsByTag := make(map[int64]map[string]string)
+	for _, translation := range translations {
+		if translationsByTag[translation.TagId] == nil {
+			translationsByTag[translation.TagId] = make(map[string]string)
+		}
+		translationsByTag[translation.TagId][translation.Locale] = translation.Name
+	}
+
 	var tagListResponse []*TagResponse
 	for rows.Next() {
 		// 理论上循环体内使用var声明的变量，在每次迭代时都是一个新的变量，不会互相影响。绝大多数循环的临时变量都保存在栈上。在不逃逸的情况下，栈空间可能会被复用。
@@ -103,9 +268,19 @@ func (tr *tagRepository) ListTags() ([]*TagResponse, error) {
 		var tag TagResponse
 		var channelStrTmp sql.NullString
 		var channelStr string
-		if err := rows.Scan(&tag.Id, &tag.Name, &channelStrTmp); err != nil {
+		var groupIdTmp sql.NullInt64
+		var triggersTmp sql.NullString
+		var exclusiveTmp sql.NullBool
+		if err := rows.Scan(&tag.Id, &tag.Name, &groupIdTmp, &triggersTmp, &exclusiveTmp, &channelStrTmp); err != nil {
 			return nil, err
 		}
+		if groupIdTmp.Valid {
+			tag.GroupId = groupIdTmp.Int64
+		}
+		if triggersTmp.Valid && triggersTmp.String != "" {
+			tag.Triggers = strings.Split(triggersTmp.String, "\n")
+		}
+		tag.Exclusive = exclusiveTmp.Valid && exclusiveTmp.Bool
 		if channelStrTmp.Valid {
 			channelStr = channelStrTmp.String
 		} else {
@@ -124,6 +299,7 @@ func (tr *tagRepository) ListTags() ([]*TagResponse, error) {
 			}
 			channelStr = rest
 		}
+		tag.Translations = translationsByTag[tag.Id]
 		tagListResponse = append(tagListResponse, &tag) // 使用指针避免值拷贝，确保切片中存储的是同一份 tag 实例，节省内存并保证后续若修改 tag 会反映到切片中
 	}
 	if err := rows.Err(); err != nil {
@@ -131,3 +307,104 @@ func (tr *tagRepository) ListTags() ([]*TagResponse, error) {
 	}
 	return tagListResponse, nil
 }
+
+func (tr *tagRepository) SetTagTranslations(id int, translations map[string]string) error {
+	normalized, err := normalizeTranslations(translations)
+	if err != nil {
+		return err
+	}
+	return tr.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&Tag{}, id).Error; err != nil {
+			log.Printf("查询标签失败: %v", err)
+			return errors.New("标签不存在")
+		}
+		if err := tx.Delete(&TagTranslation{}, "tag_id = ?", id).Error; err != nil {
+			log.Printf("删除标签译名失败: %v", err)
+			return errors.New("修改标签译名失败")
+		}
+		if err := createTranslations(tx, int64(id), normalized); err != nil {
+			return err
+		}
+		return checkLocalizedNamesUnique(tx, int64(id))
+	})
+}
+
+// 检查标签名是否已被其他标签使用（不区分大小写），excludeId 为修改标签时排除的标签自身。
+// SQLite 的 LOWER 只转换 ASCII 字符，因此在 Go 中比较
+// 在事务tx中检查标签修改后在各语言下的名称是否与其他标签重复，应在写入标签名及译名之后调用
+func checkLocalizedNamesUnique(tx *gorm.DB, tagId int64) error {
+	var tags []*Tag
+	var translations []*TagTranslation
+	if err := tx.Find(&tags).Error; err != nil {
+		log.Printf("查询标签失败: %v", err)
+		return errors.New("查询标签失败")
+	}
+	if err := tx.Find(&translations).Error; err != nil {
+		log.Printf("查询标签译名失败: %v", err)
+		return errors.New("查询标签译名失败")
+	}
+	names := make([]services.TagNames, 0, len(tags))
+	for _, tag := range tags {
+		tagNames := services.TagNames{Id: tag.Id, Name: tag.Name, Translations: make(map[string]string)}
+		for _, translation := range translations {
+			if translation.TagId == tag.Id {
+				tagNames.Translations[translation.Locale] = translation.Name
+			}
+		}
+		names = append(names, tagNames)
+	}
+	return services.CheckLocalizedTagNames(names, tagId)
+}
+
+func checkTagNameUnique(tx *gorm.DB, name string, excludeId int64) error {
+	var names []string
+	if err := tx.Model(&Tag{}).Where("id <> ?", excludeId).Pluck("name", &names).Error; err != nil {
+		log.Printf("查询标签名失败: %v", err)
+		return errors.New("查询标签名失败")
+	}
+	for _, existing := range names {
+		if strings.EqualFold(existing, name) {
+			return errors.New("标签名已存在")
+		}
+	}
+	return nil
+}
+
+// 规范化标签译名：语言标签按 BCP 47 规范化，译名按标签名规则规范化
+func normalizeTranslations(translations map[string]string) (map[string]string, error) {
+	var errs services.ValidationErrors
+	normalized := make(map[string]string, len(translations))
+	for locale, name := range translations {
+		normalizedLocale, err := services.NormalizeLocale(locale)
+		if err != nil {
+			errs = append(errs, services.FieldError{Field: "translations", Message: err.Error()})
+			continue
+		}
+		if normalizedLocale == "" {
+			errs = append(errs, services.FieldError{Field: "translations", Message: "译名的语言标签不能为空"})
+			continue
+		}
+		normalizedName, err := services.NormalizeHashtag(name)
+		if err != nil {
+			errs = append(errs, services.FieldError{Field: "translations", Message: fmt.Sprintf("%s 译名：%v", normalizedLocale, err)})
+			continue
+		}
+		normalized[normalizedLocale] = normalizedName
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return normalized, nil
+}
+
+// 保存标签译名
+func createTranslations(tx *gorm.DB, tagId int64, translations map[string]string) error {
+	for locale, name := range translations {
+		translation := TagTranslation{TagId: tagId, Locale: locale, Name: name}
+		if err := tx.Create(&translation).Error; err != nil {
+			log.Printf("保存标签译名失败: %v", err)
+			return errors.New("保存标签译名失败")
+		}
+	}
+	return nil
+}
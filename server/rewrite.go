@@ -0,0 +1,84 @@
+// AI 改写主题：调用改写服务得到多个主题，并为每个主题按频道设置生成标题
+package server
+
+import (
+	"errors"
+	"fmt"
+
+	"fswrhzl/ytb_title/server/services"
+)
+
+// 一次 AI 改写的主题及数量
+type rewriteKey struct {
+	theme string
+	count int
+}
+
+// 一次 AI 改写的结果
+type themeRewrite struct {
+	themes []string
+	err    error
+}
+
+// 使用 AI 将主题改写为最多count个说法，每个不超过maxLength个字符。
+// 同一请求中（为多个频道生成时）相同的主题只调用一次改写接口，之后直接返回第一次的结果（包括失败），
+// 因此改写时使用的是第一个频道的主题长度限制，超出其他频道限制的改写结果由 generateRewrittenTitles 舍弃
+func (data *titleData) rewriteTheme(theme string, count int, maxLength int) ([]string, error) {
+	key := rewriteKey{theme: theme, count: count}
+	if rewrite, ok := data.rewrites[key]; ok {
+		return rewrite.themes, rewrite.err
+	}
+	rewrite := &themeRewrite{}
+	if themeRewriter == nil {
+		rewrite.err = errors.New("未配置 AI 改写接口")
+	} else if rewrite.themes, rewrite.err = themeRewriter.Rewrite(theme, count, maxLength); rewrite.err != nil {
+		fmt.Printf("AI 改写主题失败：%v\n", rewrite.err)
+		rewrite.err = errors.New("AI 改写主题失败")
+	}
+	if data.rewrites == nil {
+		data.rewrites = make(map[rewriteKey]*themeRewrite)
+	}
+	data.rewrites[key] = rewrite
+	return rewrite.themes, rewrite.err
+}
+
+// 使用 AI 将主题改写为最多count个说法，为每个改写后的主题各生成一个标题。
+// 话题标签的选择与原主题相同（使用同一个种子及候选标签），只有主题不同；超出主题长度限制或包含禁用词的改写结果被舍弃。
+// 返回生成的标题、每个标题使用的改写后的主题及第一个标题的生成输入；未配置改写接口、改写失败或没有可用的改写结果时返回错误，调用方应按原主题生成
+func generateRewrittenTitles(data *titleData, theme string, count int, seed int64, spec *services.TitleSpec, limits services.TitleLimits,
+	bannedWords []services.BannedWord, newRender func(theme string) (func(hashtags string) string, error)) ([]services.GeneratedTitle, []string, *services.TitleSpec, error) {
+	themes, err := data.rewriteTheme(theme, count, limits.ThemeLength())
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	generated := make([]services.GeneratedTitle, 0, len(themes))
+	generatedThemes := make([]string, 0, len(themes))
+	var firstSpec *services.TitleSpec
+	for _, rewritten := range themes {
+		if spec.Measurer.Measure(rewritten) > limits.ThemeLength() {
+			continue
+		}
+		if _, matched := services.FilterBannedWords(rewritten, bannedWords); len(matched) > 0 {
+			continue
+		}
+		render, err := newRender(rewritten)
+		if err != nil {
+			continue
+		}
+		rewrittenSpec := *spec
+		rewrittenSpec.Render = render
+		titles, err := services.GenerateTitles(&rewrittenSpec, 1, seed)
+		if err != nil || len(titles) == 0 {
+			continue
+		}
+		if firstSpec == nil {
+			firstSpec = &rewrittenSpec
+		}
+		generated = append(generated, titles[0])
+		generatedThemes = append(generatedThemes, rewritten)
+	}
+	if len(generated) == 0 {
+		return nil, nil, nil, errors.New("AI 改写的主题均不可用")
+	}
+	return generated, generatedThemes, firstSpec, nil
+}
@@ -2,18 +2,19 @@ package server
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	"math/rand"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
-	"unicode/utf8"
 
 	// "fswrhzl/ytb_title/server/db"
 	"fswrhzl/ytb_title/server/cache"
 	mGorm "fswrhzl/ytb_title/server/gorm"
 	"fswrhzl/ytb_title/server/middleware"
+	"fswrhzl/ytb_title/server/services"
 
 	"github.com/gin-gonic/gin"
 )
@@ -21,20 +22,51 @@ import (
 type (
 	// 生成标签请求
 	TitleRequest struct {
-		Theme   string `form:"theme" binding:"required" json:"theme"`
-		Channel int    `form:"channel" binding:"required" json:"channel"`
+		Theme    string `form:"theme" binding:"required" json:"theme"`
+		Channel  int    `form:"channel" json:"channel"`
+		Channels []int  `form:"channels" json:"channels"`   // 同时为多个频道生成标题，设置后忽略 Channel
+		Count    int    `form:"count" json:"count"`         // 需要生成的候选标题数量，默认为1
+		Seed     *int64 `form:"seed" json:"seed"`           // 随机种子，相同的种子、主题、频道和标签集合总是生成相同的标题；为空时随机生成
+		Episode  int    `form:"episode" json:"episode"`     // 集数，用于频道标题模板中的 {{.Episode}}
+		Explain  bool   `form:"explain" json:"explain"`     // 返回生成过程说明（候选标签池、选中及未被选中的标签），且不保存历史（试运行）
+		Counter  string `form:"counter" json:"counter"`     // 频道的集数计数器名称，设置且未指定 Episode 时使用计数器的下一集
+		Rewrite  bool   `form:"rewrite" json:"rewrite"`     // 使用 AI 改写主题，为每个改写后的主题生成一个标题，Count 为改写的数量
+		UsedOnly bool   `form:"used_only" json:"used_only"` // 检查重复标题时只与已确认使用的历史标题比较，默认与最近生成的历史标题比较
 	}
 )
 
+const (
+	maxTitleCount        = 10      // 单次请求最多生成的候选标题数量
+	maxChannelsPerTitle  = 20      // 单次请求最多同时生成标题的频道数量
+	maxSeed              = 1 << 53 // 随机生成的种子上限，保证种子在前端（JavaScript）中能被精确表示
+	duplicateHistorySize = 200     // 检查重复标题时最多比较的频道历史标题数量
+)
+
 var (
-	channelRepository mGorm.ChannelRepository
-	tagRepository     mGorm.TagRepository
-	localCache        = cache.NewLocalCache(10 * time.Minute)
+	channelRepository        mGorm.ChannelRepository
+	tagRepository            mGorm.TagRepository
+	tagGroupRepository       mGorm.TagGroupRepository
+	tagUsageRepository       mGorm.TagUsageRepository
+	historyRepository        mGorm.HistoryRepository
+	tagRuleRepository        mGorm.TagRuleRepository
+	bannedWordRepository     mGorm.BannedWordRepository
+	episodeCounterRepository mGorm.EpisodeCounterRepository
+	themeRewriter            *services.ThemeRewriter // 未配置 AI 改写接口时为 nil
+	localCache               = cache.NewLocalCache(10 * time.Minute)
 )
 
 func SetupRouter() *gin.Engine {
 	channelRepository = mGorm.NewChannelRepository()
 	tagRepository = mGorm.NewTagRepository()
+	tagGroupRepository = mGorm.NewTagGroupRepository()
+	tagUsageRepository = mGorm.NewTagUsageRepository()
+	historyRepository = mGorm.NewHistoryRepository()
+	tagRuleRepository = mGorm.NewTagRuleRepository()
+	bannedWordRepository = mGorm.NewBannedWordRepository()
+	episodeCounterRepository = mGorm.NewEpisodeCounterRepository()
+	if config, ok := services.RewriteConfigFromEnv(); ok {
+		themeRewriter = services.NewThemeRewriter(config)
+	}
 	r := gin.Default()
 	err := r.SetTrustedProxies(nil)
 	if err != nil {
@@ -50,6 +82,8 @@ func SetupRouter() *gin.Engine {
 	{
 		// 生成标题
 		api.POST("/generate-title", generateTitle)
+		// 批量生成标题
+		api.POST("/generate-title/batch", generateTitleBatch)
 		// 获取所有频道
 		api.GET("/channels", getChannels)
 		// 编辑频道
@@ -58,12 +92,46 @@ func SetupRouter() *gin.Engine {
 		api.POST("/channels", createChannel)
 		// 删除频道
 		api.DELETE("/channels/:id", deleteChannel)
+		// 获取频道的集数计数器
+		api.GET("/channels/:id/counters", getEpisodeCounters)
+		// 设置频道的集数计数器
+		api.PUT("/channels/:id/counters/:name", setEpisodeCounter)
+		// 重置频道的集数计数器
+		api.POST("/channels/:id/counters/:name/reset", resetEpisodeCounter)
 		// 获取所有标签
 		api.GET("/tags", getTags)
 		// 新增标签
 		api.POST("/tags", createTag)
+		// 修改标签（重命名并替换关联频道）
+		api.PUT("/tags/:id", updateTag)
 		// 删除标签
 		api.DELETE("/tags/:id", deleteTag)
+		// 修改标签译名
+		api.PUT("/tags/:id/translations", updateTagTranslations)
+		// 获取所有标签分组
+		api.GET("/tag-groups", getTagGroups)
+		// 新增标签分组
+		api.POST("/tag-groups", createTagGroup)
+		// 删除标签分组
+		api.DELETE("/tag-groups/:id", deleteTagGroup)
+		// 获取所有标签兼容规则
+		api.GET("/tag-rules", getTagRules)
+		// 新增标签兼容规则
+		api.POST("/tag-rules", createTagRule)
+		// 修改标签兼容规则
+		api.PUT("/tag-rules/:id", updateTagRule)
+		// 删除标签兼容规则
+		api.DELETE("/tag-rules/:id", deleteTagRule)
+		// 获取所有禁用词
+		api.GET("/banned-words", getBannedWords)
+		// 新增禁用词
+		api.POST("/banned-words", createBannedWord)
+		// 删除禁用词
+		api.DELETE("/banned-words/:id", deleteBannedWord)
+		// 查询标题生成历史
+		api.GET("/history", getHistory)
+		// 确认使用历史标题，使用了集数计数器时计数器推进到该标题的集数
+		api.POST("/history/:id/use", useHistory)
 	}
 	return r
 }
@@ -112,10 +180,10 @@ func createChannel(c *gin.Context) {
 		return
 	}
 	fmt.Printf("channel: %v+\n", channel)
-	if channel.Name == "" {
+	if err := validateChannelSettings(&channel); err != nil {
 		c.JSON(http.StatusOK, gin.H{
 			"status":  "error",
-			"message": "名称不能为空",
+			"message": err.Error(),
 		})
 		return
 	}
@@ -143,10 +211,10 @@ func updateChannel(c *gin.Context) {
 		})
 		return
 	}
-	if channelUpdateRequest.Name == "" {
+	if err := validateChannelSettings(&channelUpdateRequest.ChannelCreateRequest); err != nil {
 		c.JSON(http.StatusOK, gin.H{
 			"status":  "error",
-			"message": "名称不能为空",
+			"message": err.Error(),
 		})
 		return
 	}
@@ -179,6 +247,8 @@ func deleteChannel(c *gin.Context) {
 		})
 	}
 	localCache.Delete("channels")
+	// 频道的禁用词随频道一并删除
+	localCache.Delete("banned_words")
 	c.JSON(http.StatusOK, gin.H{
 		"status":  "success",
 		"message": "频道删除成功",
@@ -236,11 +306,26 @@ func createTag(c *gin.Context) {
 		})
 		return
 	}
-	// 创建标签
-	// 标签名统一为小写
-	tagCreateRequest.Name = strings.ToLower(tagCreateRequest.Name)
+	// 权重为0时使用默认权重
+	if tagCreateRequest.Weight < 0 || tagCreateRequest.Weight > mGorm.MaxTagWeight {
+		c.JSON(http.StatusOK, gin.H{
+			"status":  "error",
+			"message": errTagWeight.Error(),
+		})
+		return
+	}
+	// 创建标签，标签名在仓储层按 YouTube 话题标签规则统一规范化
 	if err := tagRepository.CreateTag(&tagCreateRequest); err != nil {
 		fmt.Printf("创建标签失败：%v\n", err)
+		var validationErrors services.ValidationErrors
+		if errors.As(err, &validationErrors) {
+			c.JSON(http.StatusOK, gin.H{
+				"status":  "error",
+				"message": err.Error(),
+				"errors":  validationErrors,
+			})
+			return
+		}
 		// 关于http.StatusOK状态的使用，能够给出明确提示，且不泄露内部信息的错误，都应该返回http.StatusOK状态码
 		c.JSON(http.StatusOK, gin.H{
 			"status":  "error",
@@ -248,14 +333,72 @@ func createTag(c *gin.Context) {
 		})
 		return
 	}
-	// 刷新tag数据
+	// 刷新tag数据，频道数据中包含关联的标签，一并刷新
 	localCache.Delete("tags")
+	localCache.Delete("channels")
 	c.JSON(http.StatusOK, gin.H{
 		"status":  "success",
 		"message": "标签创建成功",
 	})
 }
 
+// 修改标签：重命名标签并替换关联的频道
+func updateTag(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{"error": "ID 格式错误"})
+		return
+	}
+	var tagUpdateRequest mGorm.TagUpdateRequest
+	if err := c.ShouldBindJSON(&tagUpdateRequest); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"status":  "error",
+			"message": "错误的请求参数",
+		})
+		return
+	}
+	if tagUpdateRequest.Name == "" || len(tagUpdateRequest.Channels) == 0 {
+		c.JSON(http.StatusOK, gin.H{
+			"status":  "error",
+			"message": "标签名、频道不能为空",
+		})
+		return
+	}
+	// 权重为0时使用默认权重
+	if tagUpdateRequest.Weight < 0 || tagUpdateRequest.Weight > mGorm.MaxTagWeight {
+		c.JSON(http.StatusOK, gin.H{
+			"status":  "error",
+			"message": errTagWeight.Error(),
+		})
+		return
+	}
+	if err := tagRepository.UpdateTag(id, &tagUpdateRequest); err != nil {
+		var validationErrors services.ValidationErrors
+		if errors.As(err, &validationErrors) {
+			c.JSON(http.StatusOK, gin.H{
+				"status":  "error",
+				"message": err.Error(),
+				"errors":  validationErrors,
+			})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+	// 频道数据中包含关联的标签，兼容规则中包含标签名，一并刷新
+	localCache.Delete("tags")
+	localCache.Delete("channels")
+	localCache.Delete("tag_rules")
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "标签修改成功",
+	})
+}
+
 // 删除标签
 func deleteTag(c *gin.Context) {
 	idStr := c.Param("id")
@@ -272,107 +415,613 @@ func deleteTag(c *gin.Context) {
 		return
 	}
 	localCache.Delete("tags")
+	localCache.Delete("channels")
+	// 标签的兼容规则随标签一并删除
+	localCache.Delete("tag_rules")
 	c.JSON(http.StatusOK, gin.H{
 		"status":  "success",
 		"message": "标签删除成功",
 	})
 }
 
+// 修改标签译名，替换标签已有的全部译名
+func updateTagTranslations(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{"error": "ID 格式错误"})
+		return
+	}
+	var translationsRequest mGorm.TagTranslationsRequest
+	if err := c.ShouldBindJSON(&translationsRequest); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"status":  "error",
+			"message": "错误的请求参数",
+		})
+		return
+	}
+	if err := tagRepository.SetTagTranslations(id, translationsRequest.Translations); err != nil {
+		var validationErrors services.ValidationErrors
+		if errors.As(err, &validationErrors) {
+			c.JSON(http.StatusOK, gin.H{
+				"status":  "error",
+				"message": err.Error(),
+				"errors":  validationErrors,
+			})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+	localCache.Delete("tags")
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "标签译名修改成功",
+	})
+}
+
+// 获取所有标签分组
+func getTagGroups(c *gin.Context) {
+	groups, err := tagGroupRepository.ListTagGroups()
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"status":  "error",
+			"message": "暂时无法获取标签分组数据",
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "获取标签分组成功",
+		"groups":  groups,
+	})
+}
+
+// 新增标签分组
+func createTagGroup(c *gin.Context) {
+	var tagGroupCreateRequest mGorm.TagGroupCreateRequest
+	if err := c.ShouldBindJSON(&tagGroupCreateRequest); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"status":  "error",
+			"message": "错误的请求参数",
+		})
+		return
+	}
+	tagGroupCreateRequest.Name = strings.TrimSpace(tagGroupCreateRequest.Name)
+	if tagGroupCreateRequest.Name == "" {
+		c.JSON(http.StatusOK, gin.H{
+			"status":  "error",
+			"message": "分组名称不能为空",
+		})
+		return
+	}
+	if err := tagGroupRepository.CreateTagGroup(&tagGroupCreateRequest); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "标签分组创建成功",
+	})
+}
+
+// 删除标签分组
+func deleteTagGroup(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{"error": "ID 格式错误"})
+		return
+	}
+	if err := tagGroupRepository.DeleteTagGroup(id); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+	// 分组下的标签与频道配额已变化，刷新缓存
+	localCache.Delete("tags")
+	localCache.Delete("channels")
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "标签分组删除成功",
+	})
+}
+
+// 查询标题生成历史
+func getHistory(c *gin.Context) {
+	var historyQuery mGorm.HistoryQuery
+	if err := c.ShouldBindQuery(&historyQuery); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"status":  "error",
+			"message": "错误的请求参数",
+		})
+		return
+	}
+	if historyQuery.Page <= 0 {
+		historyQuery.Page = 1
+	}
+	if historyQuery.PageSize <= 0 {
+		historyQuery.PageSize = mGorm.DefaultHistoryPageSize
+	}
+	if historyQuery.PageSize > mGorm.MaxHistoryPageSize {
+		historyQuery.PageSize = mGorm.MaxHistoryPageSize
+	}
+	histories, total, err := historyRepository.ListHistory(&historyQuery)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":    "success",
+		"message":   "获取标题历史成功",
+		"histories": histories,
+		"total":     total,
+		"page":      historyQuery.Page,
+		"page_size": historyQuery.PageSize,
+	})
+}
+
 // 生成标题
 func generateTitle(c *gin.Context) {
 	var titleRequest TitleRequest
-	if err := c.ShouldBind(&titleRequest); err != nil {
+	if err := c.ShouldBind(&titleRequest); err != nil || (titleRequest.Channel == 0 && len(titleRequest.Channels) == 0) {
 		c.JSON(http.StatusOK, gin.H{
 			"status":  "error",
 			"message": "错误的请求参数",
 		})
 		return
 	}
-	if utf8.RuneCountInString(titleRequest.Theme) > 100 {
+	if len(titleRequest.Channels) > maxChannelsPerTitle {
 		c.JSON(http.StatusOK, gin.H{
 			"status":  "error",
-			"message": "标题长度不能超过100个字符",
+			"message": fmt.Sprintf("单次最多为%d个频道生成标题", maxChannelsPerTitle),
 		})
 		return
 	}
-	var tagIds []int64
-	var channels []*mGorm.ChannelResponse
-	channelsStr, err := localCache.GetWithAutoRefresh("channels", 10*time.Minute, func() (string, error) {
-		fmt.Println("本地缓存未发现channels数据，调用数据库获取channels数据")
-		channelsTmp, err := channelRepository.GetAllChannels()
-		if err != nil {
-			return "", err
+	// 频道、标签等数据只加载一次，多个频道共用
+	data, err := loadTitleData()
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+	if len(titleRequest.Channels) > 0 {
+		// 为每个频道生成标题，重复的频道只生成一次；某个频道失败不影响其他频道
+		results := make([]*channelTitleResult, 0, len(titleRequest.Channels))
+		failed := 0
+		for _, channelId := range titleRequest.Channels {
+			if slices.ContainsFunc(results, func(r *channelTitleResult) bool { return r.Channel == channelId }) {
+				continue
+			}
+			channelRequest := titleRequest
+			channelRequest.Channel = channelId
+			result, err := generateTitles(&channelRequest, data)
+			if err != nil {
+				failed++
+			}
+			results = append(results, newChannelTitleResult(channelId, result, err))
 		}
-		channelsStrTmp, err := json.Marshal(channelsTmp)
-		if err != nil {
-			return "", err
+		c.JSON(http.StatusOK, gin.H{
+			"status":  "success",
+			"message": fmt.Sprintf("已为%d个频道生成标题，失败%d个", len(results)-failed, failed),
+			"results": results,
+		})
+		return
+	}
+	result, err := generateTitles(&titleRequest, data)
+	if err != nil {
+		var bannedErr *bannedWordsError
+		if errors.As(err, &bannedErr) {
+			c.JSON(http.StatusOK, gin.H{
+				"status":       "error",
+				"message":      err.Error(),
+				"banned_words": bannedErr.Matches,
+			})
+			return
 		}
-		return string(channelsStrTmp), nil
+		c.JSON(http.StatusOK, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":    "success",
+		"message":   result.Message,
+		"title":     result.Title,
+		"titles":    result.Titles,
+		"requested": result.Requested,
+		"seed":      result.Seed,
+		// 确认使用标题时提交对应的历史记录ID，使用了集数计数器时计数器随之增加
+		"episode":     result.Episode,
+		"history_ids": result.HistoryIds,
+		// YouTube 上传页面的“标签”字段，长度按 YouTube 规则计算
+		"tags_field":        result.TagsField,
+		"tags_field_length": result.TagsFieldLength,
+		"banned_words":      result.BannedWords,
+		"duplicates":        result.Duplicates,
+		"rewritten":         result.Rewritten,
+		"explanation":       result.Explanation,
+	})
+}
+
+// 校验新增、编辑频道时提交的设置，语言标签统一转换为 BCP 47 格式
+func validateChannelSettings(channel *mGorm.ChannelCreateRequest) error {
+	if channel.Name == "" {
+		return errors.New("名称不能为空")
+	}
+	if !validTagWeights(channel.TagWeights) {
+		return errTagWeight
+	}
+	if err := validateTitleTemplate(channel.TitleTemplate); err != nil {
+		return err
+	}
+	if err := validateGroupQuotas(channel.GroupQuotas); err != nil {
+		return err
+	}
+	// 独占标签不能置顶
+	tags, err := loadTags()
+	if err != nil {
+		fmt.Printf("获取标签列表失败：%v\n", err)
+		return errors.New("无法获取标签数据")
+	}
+	exclusive := make(map[int64]string)
+	for _, tag := range tags {
+		if tag.Exclusive {
+			exclusive[tag.Id] = tag.Name
+		}
+	}
+	if err := services.ValidatePinnedTags(channel.PinnedTags, channel.Tags, exclusive); err != nil {
+		return err
+	}
+	if _, err := services.NewLengthMeasurer(channel.LengthMetric); err != nil {
+		return err
+	}
+	if _, err := services.NewTitleStrategy(channel.TitleStrategy, nil); err != nil {
+		return err
+	}
+	locale, err := services.NormalizeLocale(channel.Locale)
+	if err != nil {
+		return err
+	}
+	channel.Locale = locale
+	decoration := services.TitleDecoration{
+		Prefix:           channel.TitlePrefix,
+		Suffix:           channel.TitleSuffix,
+		HashtagSeparator: channel.HashtagSeparator,
+		HashtagPlacement: channel.HashtagPlacement,
+		HashtagCase:      channel.HashtagCase,
+	}
+	if err := decoration.Validate(); err != nil {
+		return err
+	}
+	limits := services.TitleLimits{
+		MaxTitleLength: channel.MaxTitleLength,
+		MaxThemeLength: channel.MaxThemeLength,
+		MaxHashtags:    channel.MaxHashtags,
+	}
+	if err := limits.Validate(); err != nil {
+		return err
+	}
+	if channel.RecentWindow < 0 || channel.RecentWindow > mGorm.MaxRecentWindow {
+		return fmt.Errorf("近期标签回避次数必须在0到%d之间", mGorm.MaxRecentWindow)
+	}
+	return nil
+}
+
+// 标签权重超出范围时的提示，新增、修改标签及频道时使用相同的提示
+var errTagWeight = fmt.Errorf("标签权重必须在0到%d之间，0表示使用默认权重（%d）", mGorm.MaxTagWeight, mGorm.DefaultTagWeight)
+
+// 校验频道中设置的标签权重，0表示使用默认权重
+func validTagWeights(weights map[int64]int) bool {
+	for _, weight := range weights {
+		if weight < 0 || weight > mGorm.MaxTagWeight {
+			return false
+		}
+	}
+	return true
+}
+
+// 校验频道的标签分组配额
+func validateGroupQuotas(quotas []mGorm.GroupQuota) error {
+	seen := make(map[int64]bool)
+	for _, quota := range quotas {
+		if quota.GroupId <= 0 {
+			return errors.New("分组配额必须指定标签分组")
+		}
+		if seen[quota.GroupId] {
+			return errors.New("同一标签分组只能设置一个配额")
+		}
+		seen[quota.GroupId] = true
+		if quota.Min < 0 || quota.Max < 0 {
+			return errors.New("分组配额不能为负数")
+		}
+		if quota.Max > 0 && quota.Max < quota.Min {
+			return errors.New("分组配额的最大数量不能小于最少数量")
+		}
+	}
+	return nil
+}
+
+// 获取所有标签兼容规则
+func getTagRules(c *gin.Context) {
+	rules, err := tagRuleRepository.ListTagRules()
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"status":  "error",
+			"message": "暂时无法获取标签兼容规则数据",
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "获取标签兼容规则成功",
+		"rules":   rules,
+	})
+}
+
+// 新增标签兼容规则，保存前检查与已有规则是否构成依赖循环或矛盾
+func createTagRule(c *gin.Context) {
+	var tagRuleRequest mGorm.TagRuleRequest
+	if err := c.ShouldBindJSON(&tagRuleRequest); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"status":  "error",
+			"message": "错误的请求参数",
+		})
+		return
+	}
+	if err := tagRuleRepository.CreateTagRule(&tagRuleRequest); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+	localCache.Delete("tag_rules")
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "标签兼容规则创建成功",
 	})
+}
+
+// 修改标签兼容规则，保存前检查与其他规则是否构成依赖循环或矛盾
+func updateTagRule(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		fmt.Printf("获取频道列表失败：%v\n", err)
+		c.JSON(http.StatusOK, gin.H{"error": "ID 格式错误"})
+		return
+	}
+	var tagRuleRequest mGorm.TagRuleRequest
+	if err := c.ShouldBindJSON(&tagRuleRequest); err != nil {
 		c.JSON(http.StatusOK, gin.H{
 			"status":  "error",
-			"message": "无法获取频道数据，生成标题失败",
+			"message": "错误的请求参数",
 		})
 		return
 	}
-	_ = json.Unmarshal([]byte(channelsStr), &channels)
-	for _, channel := range channels {
-		if channel.Id == int64(titleRequest.Channel) {
-			tagIds = channel.Tags
-			break
-		}
+	if err := tagRuleRepository.UpdateTagRule(id, &tagRuleRequest); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
 	}
-	var finalTitle string = titleRequest.Theme
-	if len(tagIds) > 0 {
-		needTags := make([]string, 0)
-		var tags []*mGorm.TagResponse
-		tagsStr, err := localCache.GetWithAutoRefresh("tags", 10*time.Minute, func() (string, error) {
-			fmt.Println("本地缓存未发现tags数据，调用数据库获取tags数据")
-			tagsTmp, err := tagRepository.ListTags()
-			if err != nil {
-				return "", err
-			}
-			tagsStrTmp, err := json.Marshal(tagsTmp)
-			if err != nil {
-				return "", err
-			}
-			return string(tagsStrTmp), nil
+	localCache.Delete("tag_rules")
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "标签兼容规则修改成功",
+	})
+}
+
+func deleteTagRule(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{"error": "ID 格式错误"})
+		return
+	}
+	if err := tagRuleRepository.DeleteTagRule(id); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+	localCache.Delete("tag_rules")
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "标签兼容规则删除成功",
+	})
+}
+
+// 获取所有禁用词，可按频道筛选（同时返回对所有频道生效的禁用词）
+func getBannedWords(c *gin.Context) {
+	words, err := bannedWordRepository.ListBannedWords()
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"status":  "error",
+			"message": "暂时无法获取禁用词数据",
 		})
+		return
+	}
+	if channelStr := c.Query("channel"); channelStr != "" {
+		channelId, err := strconv.ParseInt(channelStr, 10, 64)
 		if err != nil {
-			fmt.Printf("获取标签列表失败：%v\n", err)
 			c.JSON(http.StatusOK, gin.H{
 				"status":  "error",
-				"message": "无法获取标签数据，生成标题失败",
+				"message": "错误的请求参数",
 			})
 			return
 		}
-		_ = json.Unmarshal([]byte(tagsStr), &tags)
+		words = slices.DeleteFunc(words, func(word *mGorm.BannedWord) bool {
+			return word.ChannelId != 0 && word.ChannelId != channelId
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "获取禁用词成功",
+		"words":   words,
+	})
+}
 
-		for _, tagId := range tagIds {
-			for _, tag := range tags {
-				if tag.Id == int64(tagId) {
-					needTags = append(needTags, tag.Name)
-				}
-			}
-		}
-		for utf8.RuneCountInString(finalTitle) < 100 && len(needTags) > 0 {
-			// 从needTags中随机选择一个标签
-			tmpIndex := rand.Intn(len(needTags))
-			tmp := " #" + needTags[tmpIndex]
-			// 从needTags中删除已选择的标签
-			needTags = append(needTags[:tmpIndex], needTags[tmpIndex+1:]...)
-			if utf8.RuneCountInString(finalTitle)+utf8.RuneCountInString(tmp) > 100 {
-				break
-			}
-			finalTitle += tmp
-		}
+func createBannedWord(c *gin.Context) {
+	var bannedWordCreateRequest mGorm.BannedWordCreateRequest
+	if err := c.ShouldBindJSON(&bannedWordCreateRequest); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"status":  "error",
+			"message": "错误的请求参数",
+		})
+		return
+	}
+	if err := bannedWordRepository.CreateBannedWord(&bannedWordCreateRequest); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+	localCache.Delete("banned_words")
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "禁用词创建成功",
+	})
+}
+
+func deleteBannedWord(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{"error": "ID 格式错误"})
+		return
+	}
+	if err := bannedWordRepository.DeleteBannedWord(id); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+	localCache.Delete("banned_words")
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "禁用词删除成功",
+	})
+}
+
+// 确认使用历史标题
+func useHistory(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{"error": "ID 格式错误"})
+		return
+	}
+	history, counterValue, err := historyRepository.UseHistory(id)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+	response := gin.H{
+		"status":  "success",
+		"message": "已确认使用标题",
+		"history": history,
+	}
+	if history.Counter != "" {
+		response["counter"] = gin.H{"name": history.Counter, "value": counterValue}
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// 获取频道的集数计数器
+func getEpisodeCounters(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{"error": "ID 格式错误"})
+		return
+	}
+	counters, err := episodeCounterRepository.ListEpisodeCounters(int64(id))
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":   "success",
+		"message":  "获取频道计数器成功",
+		"counters": counters,
+	})
+}
+
+// 设置频道的集数计数器，计数器不存在时创建
+func setEpisodeCounter(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{"error": "ID 格式错误"})
+		return
+	}
+	var episodeCounterRequest mGorm.EpisodeCounterRequest
+	if err := c.ShouldBindJSON(&episodeCounterRequest); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"status":  "error",
+			"message": "错误的请求参数",
+		})
+		return
 	}
+	counter, err := episodeCounterRepository.SetEpisodeCounter(int64(id), c.Param("name"), *episodeCounterRequest.Value)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "频道计数器设置成功",
+		"counter": counter,
+	})
+}
 
+// 重置频道的集数计数器，重置后下一集为第1集
+func resetEpisodeCounter(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{"error": "ID 格式错误"})
+		return
+	}
+	counter, err := episodeCounterRepository.SetEpisodeCounter(int64(id), c.Param("name"), 0)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{
 		"status":  "success",
-		"message": "生成标题成功",
-		"title":   finalTitle,
+		"message": "频道计数器已重置",
+		"counter": counter,
 	})
 }
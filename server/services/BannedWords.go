@@ -0,0 +1,124 @@
+/* 禁用词服务：在 Unicode 规范化、不区分大小写的前提下查找并屏蔽主题及标签中的禁用词 */
+package services
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// 命中禁用词时的处理方式
+const (
+	BannedWordReject = "reject" // 拒绝生成（主题）或舍弃（标签）
+	BannedWordMask   = "mask"   // 将主题中的禁用词替换为*，标签仍会被舍弃
+)
+
+// 一个禁用词，Word 应已经过 NormalizeBannedWord 规范化
+type BannedWord struct {
+	Word   string `json:"word"`
+	Action string `json:"action"`
+}
+
+// 规范化文本：NFKC 规范化并转换为小写
+func normalizeBannedText(s string) string {
+	return strings.ToLower(norm.NFKC.String(s))
+}
+
+// NormalizeBannedWord 规范化禁用词，规范化后为空时返回 ValidationErrors
+func NormalizeBannedWord(word string) (string, error) {
+	normalized := strings.TrimSpace(normalizeBannedText(word))
+	if normalized == "" {
+		return "", ValidationErrors{{Field: "word", Message: "禁用词不能为空"}}
+	}
+	return normalized, nil
+}
+
+// 判断字符是否属于书写时词与词之间不加空格的文字（汉字、假名、谚文）
+func isUnspacedScript(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// 判断字符是否构成单词的一部分
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsNumber(r) || unicode.IsMark(r)
+}
+
+// 判断禁用词一侧的边界：edge 为禁用词在该侧的字符，neighbor 为文本中紧邻的字符（ok 为 false 表示到达文本两端）。
+// 紧邻字符不是单词的一部分时构成边界；中日韩文字不用空格分词，edge 或 neighbor 为中日韩文字时也视为边界
+func isBannedWordBoundary(edge, neighbor rune, ok bool) bool {
+	return !ok || !isWordRune(neighbor) || isUnspacedScript(edge) || isUnspacedScript(neighbor)
+}
+
+// FilterBannedWords 在text中查找禁用词，返回屏蔽后的文本及命中的禁用词（按words中的顺序，不重复）。
+// 只匹配完整的单词：禁用词两侧须为文本两端或非字母、数字的字符（如 kill 不会匹配 skill），
+// 中日韩文字不用空格分词，与其相邻的一侧不要求边界。
+// 查找在整体规范化后的文本上进行（分解形式的字符，如 e + 组合重音符，会先组合再匹配），
+// 处理方式为 mask 的禁用词在原文中对应的字符被替换为*，组合在一起的多个码点只替换为一个*
+func FilterBannedWords(text string, words []BannedWord) (string, []BannedWord) {
+	if len(words) == 0 || text == "" {
+		return text, nil
+	}
+	// 按规范化的分段（norm.Iter 每次返回原文中一段码点规范化后的结果）规范化整个文本，
+	// 记录每个分段在原文中的范围，以及规范化文本中每个字节所属的分段，以便在原文中屏蔽
+	var iter norm.Iter
+	iter.InitString(norm.NFKC, text)
+	var segments [][2]int
+	var normalized strings.Builder
+	segmentIndex := make([]int, 0, len(text))
+	for !iter.Done() {
+		start := iter.Pos()
+		part := strings.ToLower(string(iter.Next()))
+		segments = append(segments, [2]int{start, iter.Pos()})
+		normalized.WriteString(part)
+		for range len(part) {
+			segmentIndex = append(segmentIndex, len(segments)-1)
+		}
+	}
+	haystack := normalized.String()
+
+	var matched []BannedWord
+	masked := make([]bool, len(segments))
+	for _, word := range words {
+		if word.Word == "" {
+			continue
+		}
+		first, _ := utf8.DecodeRuneInString(word.Word)
+		last, _ := utf8.DecodeLastRuneInString(word.Word)
+		found := false
+		for offset := 0; offset < len(haystack); {
+			i := strings.Index(haystack[offset:], word.Word)
+			if i < 0 {
+				break
+			}
+			start := offset + i
+			end := start + len(word.Word)
+			_, size := utf8.DecodeRuneInString(haystack[start:])
+			offset = start + size
+			prev, prevSize := utf8.DecodeLastRuneInString(haystack[:start])
+			next, nextSize := utf8.DecodeRuneInString(haystack[end:])
+			if !isBannedWordBoundary(first, prev, prevSize > 0) || !isBannedWordBoundary(last, next, nextSize > 0) {
+				continue
+			}
+			found = true
+			if word.Action == BannedWordMask {
+				for j := segmentIndex[start]; j <= segmentIndex[end-1]; j++ {
+					masked[j] = true
+				}
+			}
+		}
+		if found {
+			matched = append(matched, word)
+		}
+	}
+	var result strings.Builder
+	for i, segment := range segments {
+		if masked[i] {
+			result.WriteByte('*')
+		} else {
+			result.WriteString(text[segment[0]:segment[1]])
+		}
+	}
+	return result.String(), matched
+}
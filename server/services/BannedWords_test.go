@@ -0,0 +1,49 @@
+package services
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestFilterBannedWords(t *testing.T) {
+	cafe, _ := NormalizeBannedWord("café")
+	spam, _ := NormalizeBannedWord("SPAM")
+	kill, _ := NormalizeBannedWord("ＫＩＬＬ")
+	damn, _ := NormalizeBannedWord("damn")
+	cunt, _ := NormalizeBannedWord("cunt")
+	gamble, _ := NormalizeBannedWord("赌博")
+	tests := []struct {
+		text        string
+		words       []BannedWord
+		want        string
+		wantMatched []string
+	}{
+		{"cafe\u0301 vlog", []BannedWord{{cafe, BannedWordReject}}, "cafe\u0301 vlog", []string{cafe}},
+		{"cafe\u0301 vlog", []BannedWord{{cafe, BannedWordMask}}, "**** vlog", []string{cafe}},
+		{"café vlog", []BannedWord{{cafe, BannedWordMask}}, "**** vlog", []string{cafe}},
+		{"no ＳＰＡＭ here", []BannedWord{{spam, BannedWordMask}}, "no **** here", []string{spam}},
+		{"spam spam", []BannedWord{{spam, BannedWordMask}, {cafe, BannedWordMask}}, "**** ****", []string{spam}},
+		{"cafe vlog", []BannedWord{{cafe, BannedWordMask}}, "cafe vlog", nil},
+		{"", []BannedWord{{cafe, BannedWordMask}}, "", nil},
+		// 只匹配完整的单词
+		{"skill showcase", []BannedWord{{kill, BannedWordReject}}, "skill showcase", nil},
+		{"Scunthorpe United", []BannedWord{{cunt, BannedWordMask}}, "Scunthorpe United", nil},
+		{"damnation", []BannedWord{{damn, BannedWordMask}}, "damnation", nil},
+		{"Damnit", []BannedWord{{damn, BannedWordReject}}, "Damnit", nil},
+		{"Damn, it's hard", []BannedWord{{damn, BannedWordMask}}, "****, it's hard", []string{damn}},
+		{"boss_kill #kill", []BannedWord{{kill, BannedWordMask}}, "boss_**** #****", []string{kill}},
+		// 中日韩文字不用空格分词
+		{"网络赌博网站", []BannedWord{{gamble, BannedWordMask}}, "网络**网站", []string{gamble}},
+		{"一键kill全场", []BannedWord{{kill, BannedWordMask}}, "一键****全场", []string{kill}},
+	}
+	for _, tt := range tests {
+		got, matched := FilterBannedWords(tt.text, tt.words)
+		var words []string
+		for _, word := range matched {
+			words = append(words, word.Word)
+		}
+		if got != tt.want || !slices.Equal(words, tt.wantMatched) {
+			t.Errorf("FilterBannedWords(%q) = %q, %v, want %q, %v", tt.text, got, words, tt.want, tt.wantMatched)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+/* 标签名规范化与校验服务：按 YouTube 话题标签规则处理标签名，供新增、修改标签时统一调用 */
+package services
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// 标签名（不含#）的最大字符数
+const MaxHashtagLength = 30
+
+// 字段级校验错误
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// 一组字段级校验错误，实现 error 接口，便于在仓储层与路由层之间传递
+type ValidationErrors []FieldError
+
+func (ve ValidationErrors) Error() string {
+	messages := make([]string, 0, len(ve))
+	for _, fe := range ve {
+		messages = append(messages, fe.Message)
+	}
+	return strings.Join(messages, "；")
+}
+
+// NormalizeHashtag 规范化标签名：
+//  1. NFKC 规范化，将全角字符转换为半角
+//  2. 去掉首尾空白及开头的#
+//  3. 去掉标签名中间的空白（YouTube 话题标签不能包含空格）
+//
+// 标签名保留原有的大小写（如 MineCraft），以便频道按 as_is、camel 设置输出；判断标签名是否重复时不区分大小写。
+//
+// 规范化后仍包含标点、符号，为空或超过最大长度时，返回 ValidationErrors
+func NormalizeHashtag(name string) (string, error) {
+	normalized := norm.NFKC.String(name)
+	normalized = strings.TrimSpace(normalized)
+	normalized = strings.TrimLeft(normalized, "#")
+	normalized = strings.Join(strings.Fields(normalized), "")
+
+	var errs ValidationErrors
+	if normalized == "" {
+		errs = append(errs, FieldError{Field: "name", Message: "标签名不能为空"})
+		return "", errs
+	}
+	// 只允许字母、数字、组合符号（部分文字需要）及下划线
+	var invalid []string
+	for _, r := range normalized {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r) || r == '_' {
+			continue
+		}
+		if s := string(r); !slices.Contains(invalid, s) {
+			invalid = append(invalid, s)
+		}
+	}
+	if len(invalid) > 0 {
+		errs = append(errs, FieldError{Field: "name", Message: fmt.Sprintf("标签名包含不允许的字符：%s", strings.Join(invalid, " "))})
+	}
+	if length := utf8.RuneCountInString(normalized); length > MaxHashtagLength {
+		errs = append(errs, FieldError{Field: "name", Message: fmt.Sprintf("标签名不能超过%d个字符，当前为%d个", MaxHashtagLength, length)})
+	}
+	if len(errs) > 0 {
+		return "", errs
+	}
+	return normalized, nil
+}
@@ -0,0 +1,33 @@
+package services
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalizeHashtag(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{"MineCraft", "MineCraft", false},
+		{"  #Boss Fight ", "BossFight", false},
+		{"ＧＡＭＩＮＧ", "GAMING", false},
+		{"boss_fight", "boss_fight", false},
+		{"游戏 实况", "游戏实况", false},
+		{"#", "", true},
+		{"x y!", "", true},
+		{"abcdefghijklmnopqrstuvwxyzabcde", "", true},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeHashtag(tt.name)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("NormalizeHashtag(%q) = %q, %v, want %q, error %v", tt.name, got, err, tt.want, tt.wantErr)
+		}
+		var validationErrors ValidationErrors
+		if err != nil && !errors.As(err, &validationErrors) {
+			t.Errorf("NormalizeHashtag(%q) error is not ValidationErrors: %v", tt.name, err)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+/* 标题长度计算服务：不同平台对 emoji、中日韩字符的计数方式不同，按频道选择长度计算方式 */
+package services
+
+import (
+	"fmt"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/rivo/uniseg"
+)
+
+// 长度计算方式
+const (
+	LengthMetricRunes     = "runes"     // Unicode 码点数（默认）
+	LengthMetricUTF16     = "utf16"     // UTF-16 码元数，与 JavaScript 的 String.length 一致
+	LengthMetricGraphemes = "graphemes" // 字素簇数，即用户感知的字符数，组合 emoji 计为1
+	LengthMetricWidth     = "width"     // 东亚显示宽度，中日韩全角字符及 emoji 计为2
+)
+
+// 长度计算策略
+type LengthMeasurer interface {
+	Measure(s string) int
+}
+
+// 将普通函数适配为 LengthMeasurer
+type LengthMeasurerFunc func(s string) int
+
+func (f LengthMeasurerFunc) Measure(s string) int {
+	return f(s)
+}
+
+var lengthMeasurers = map[string]LengthMeasurer{
+	LengthMetricRunes: LengthMeasurerFunc(utf8.RuneCountInString),
+	LengthMetricUTF16: LengthMeasurerFunc(func(s string) int {
+		n := 0
+		for _, r := range s {
+			n += utf16.RuneLen(r)
+		}
+		return n
+	}),
+	LengthMetricGraphemes: LengthMeasurerFunc(uniseg.GraphemeClusterCount),
+	LengthMetricWidth:     LengthMeasurerFunc(uniseg.StringWidth),
+}
+
+// NewLengthMeasurer 按名称获取长度计算策略，名称为空时使用码点数
+func NewLengthMeasurer(metric string) (LengthMeasurer, error) {
+	if metric == "" {
+		metric = LengthMetricRunes
+	}
+	measurer, ok := lengthMeasurers[metric]
+	if !ok {
+		return nil, fmt.Errorf("不支持的长度计算方式：%s", metric)
+	}
+	return measurer, nil
+}
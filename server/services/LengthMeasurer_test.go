@@ -0,0 +1,47 @@
+package services
+
+import "testing"
+
+func TestLengthMeasurer(t *testing.T) {
+	tests := []struct {
+		text      string
+		runes     int
+		utf16     int
+		graphemes int
+		width     int
+	}{
+		{"", 0, 0, 0, 0},
+		{"Boss Fight", 10, 10, 10, 10},
+		{"游戏实况", 4, 4, 4, 8},
+		{"ＡＢ", 2, 2, 2, 4},
+		{"cafe\u0301", 5, 5, 4, 4},
+		{"😀", 1, 2, 1, 2},
+		{"\U0001F468\u200d\U0001F469\u200d\U0001F467", 5, 8, 1, 2},
+		{"🇯🇵", 2, 4, 1, 2},
+		{"👍🏽", 2, 4, 1, 2},
+		{"한국어", 3, 3, 3, 6},
+	}
+	metrics := []string{LengthMetricRunes, LengthMetricUTF16, LengthMetricGraphemes, LengthMetricWidth}
+	for _, tt := range tests {
+		want := []int{tt.runes, tt.utf16, tt.graphemes, tt.width}
+		for i, metric := range metrics {
+			measurer, err := NewLengthMeasurer(metric)
+			if err != nil {
+				t.Fatalf("NewLengthMeasurer(%q): %v", metric, err)
+			}
+			if got := measurer.Measure(tt.text); got != want[i] {
+				t.Errorf("%s length of %q = %d, want %d", metric, tt.text, got, want[i])
+			}
+		}
+	}
+}
+
+func TestNewLengthMeasurer(t *testing.T) {
+	measurer, err := NewLengthMeasurer("")
+	if err != nil || measurer.Measure("😀") != 1 {
+		t.Errorf("NewLengthMeasurer(\"\") should count runes, got error %v", err)
+	}
+	if _, err := NewLengthMeasurer("bytes"); err == nil {
+		t.Error("NewLengthMeasurer(\"bytes\") want error")
+	}
+}
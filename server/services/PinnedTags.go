@@ -0,0 +1,27 @@
+/* 置顶标签服务：校验频道的置顶标签，置顶标签总是放入标题 */
+package services
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// ValidatePinnedTags 校验频道的置顶标签：不能重复，必须是频道关联的标签，且不能是独占标签。
+// 独占标签只在主题命中触发词时使用，与置顶标签总是放入标题相矛盾。exclusive 为独占标签的ID -> 标签名
+func ValidatePinnedTags(pinned []int64, tags []int64, exclusive map[int64]string) error {
+	seen := make(map[int64]bool)
+	for _, tagId := range pinned {
+		if seen[tagId] {
+			return errors.New("置顶标签不能重复")
+		}
+		seen[tagId] = true
+		if !slices.Contains(tags, tagId) {
+			return errors.New("置顶标签必须是频道关联的标签")
+		}
+		if name, ok := exclusive[tagId]; ok {
+			return fmt.Errorf("独占标签 #%s 不能设为置顶标签", name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidatePinnedTags(t *testing.T) {
+	exclusive := map[int64]string{3: "boss"}
+	tests := []struct {
+		name    string
+		pinned  []int64
+		tags    []int64
+		wantErr string
+	}{
+		{"none", nil, []int64{1, 2}, ""},
+		{"valid", []int64{2, 1}, []int64{1, 2, 3}, ""},
+		{"duplicate", []int64{1, 1}, []int64{1, 2}, "置顶标签不能重复"},
+		{"not linked", []int64{4}, []int64{1, 2}, "必须是频道关联的标签"},
+		{"exclusive", []int64{1, 3}, []int64{1, 2, 3}, "独占标签 #boss 不能设为置顶标签"},
+	}
+	for _, tt := range tests {
+		err := ValidatePinnedTags(tt.pinned, tt.tags, exclusive)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error %v", tt.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: error = %v, want containing %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
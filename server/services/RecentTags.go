@@ -0,0 +1,15 @@
+/* 近期标签回避服务：按标签在频道最近几次生成中的使用次数降低其权重，减少连续多次使用相同的标签 */
+package services
+
+// PenalizeRecentTags 按近期使用次数降低候选标签的权重：权重除以（使用次数 + 1），至少为1。
+// 降低权重而不是排除标签，标签不足时仍能满足分组配额；usage 为标签ID -> 近期使用次数。返回新的切片，不修改candidates
+func PenalizeRecentTags(candidates []TagCandidate, usage map[int64]int) []TagCandidate {
+	penalized := make([]TagCandidate, 0, len(candidates))
+	for _, tag := range candidates {
+		if count := usage[tag.Id]; count > 0 {
+			tag.Weight = max(tag.Weight/(count+1), 1)
+		}
+		penalized = append(penalized, tag)
+	}
+	return penalized
+}
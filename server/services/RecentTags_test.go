@@ -0,0 +1,26 @@
+package services
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPenalizeRecentTags(t *testing.T) {
+	candidates := []TagCandidate{
+		{Id: 1, Name: "a", Weight: 10},
+		{Id: 2, Name: "b", Weight: 10},
+		{Id: 3, Name: "c", Weight: 10},
+		{Id: 4, Name: "d", Weight: 1},
+	}
+	got := PenalizeRecentTags(candidates, map[int64]int{2: 1, 3: 20, 4: 3})
+	weights := make([]int, 0, len(got))
+	for _, tag := range got {
+		weights = append(weights, tag.Weight)
+	}
+	if want := []int{10, 5, 1, 1}; !slices.Equal(weights, want) {
+		t.Errorf("PenalizeRecentTags weights = %v, want %v", weights, want)
+	}
+	if candidates[1].Weight != 10 {
+		t.Error("PenalizeRecentTags modified its input")
+	}
+}
@@ -0,0 +1,80 @@
+/* 标签本地化服务：规范化语言标签，并按频道语言选择标签的译名 */
+package services
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// NormalizeLocale 将语言标签（如 zh_cn、EN-us）规范化为 BCP 47 格式（zh-CN、en-US），为空时返回空字符串
+func NormalizeLocale(locale string) (string, error) {
+	locale = strings.ReplaceAll(strings.TrimSpace(locale), "_", "-")
+	if locale == "" {
+		return "", nil
+	}
+	tag, err := language.Parse(locale)
+	if err != nil {
+		return "", fmt.Errorf("无效的语言标签：%s", locale)
+	}
+	return tag.String(), nil
+}
+
+// LocalizedTagName 返回标签在指定语言下的名称：优先使用完全匹配的译名，其次使用同一语种（如 zh-TW 使用 zh）的译名，
+// 都没有时使用标签的原名。locale 应已经过 NormalizeLocale 规范化
+func LocalizedTagName(name string, translations map[string]string, locale string) string {
+	if locale == "" || len(translations) == 0 {
+		return name
+	}
+	if translated, ok := translations[locale]; ok {
+		return translated
+	}
+	if tag, err := language.Parse(locale); err == nil {
+		base, _ := tag.Base()
+		if translated, ok := translations[base.String()]; ok {
+			return translated
+		}
+	}
+	return name
+}
+
+// 一个标签的原名及各语言的译名
+type TagNames struct {
+	Id           int64
+	Name         string
+	Translations map[string]string
+}
+
+// CheckLocalizedTagNames 检查标签id与其他标签在各语言下的名称（按 LocalizedTagName 选择）是否重复，不区分大小写。
+// 检查的语言为所有标签译名使用的语言及其语种，只报告与标签id有关的重复，已有的其他重复不影响修改
+func CheckLocalizedTagNames(tags []TagNames, id int64) error {
+	var target *TagNames
+	locales := make([]string, 0)
+	for i, tag := range tags {
+		if tag.Id == id {
+			target = &tags[i]
+		}
+		for locale := range tag.Translations {
+			locales = append(locales, locale)
+			if parsed, err := language.Parse(locale); err == nil {
+				base, _ := parsed.Base()
+				locales = append(locales, base.String())
+			}
+		}
+	}
+	if target == nil {
+		return nil
+	}
+	slices.Sort(locales)
+	for _, locale := range slices.Compact(locales) {
+		name := LocalizedTagName(target.Name, target.Translations, locale)
+		for _, tag := range tags {
+			if tag.Id != id && strings.EqualFold(LocalizedTagName(tag.Name, tag.Translations, locale), name) {
+				return fmt.Errorf("标签在 %s 下的名称 #%s 与标签 #%s 重复", locale, name, tag.Name)
+			}
+		}
+	}
+	return nil
+}
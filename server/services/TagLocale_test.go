@@ -0,0 +1,83 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeLocale(t *testing.T) {
+	tests := []struct {
+		locale  string
+		want    string
+		wantErr bool
+	}{
+		{"zh_cn", "zh-CN", false},
+		{" EN-us ", "en-US", false},
+		{"ja", "ja", false},
+		{"zh-hant-tw", "zh-Hant-TW", false},
+		{"", "", false},
+		{"   ", "", false},
+		{"not a locale", "", true},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeLocale(tt.locale)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("NormalizeLocale(%q) = %q, %v, want %q, error %v", tt.locale, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestLocalizedTagName(t *testing.T) {
+	translations := map[string]string{"zh": "游戏", "zh-TW": "遊戲", "ja": "ゲーム"}
+	tests := []struct {
+		locale       string
+		translations map[string]string
+		want         string
+	}{
+		{"zh-TW", translations, "遊戲"},
+		{"zh-CN", translations, "游戏"},
+		{"ja-JP", translations, "ゲーム"},
+		{"en-US", translations, "gaming"},
+		{"", translations, "gaming"},
+		{"zh-CN", nil, "gaming"},
+	}
+	for _, tt := range tests {
+		if got := LocalizedTagName("gaming", tt.translations, tt.locale); got != tt.want {
+			t.Errorf("LocalizedTagName(%q) = %q, want %q", tt.locale, got, tt.want)
+		}
+	}
+}
+
+func TestCheckLocalizedTagNames(t *testing.T) {
+	tags := []TagNames{
+		{Id: 1, Name: "gaming", Translations: map[string]string{"zh-CN": "游戏"}},
+		{Id: 2, Name: "game", Translations: map[string]string{"zh": "游戏"}},
+		{Id: 3, Name: "游戏"},
+		{Id: 4, Name: "boss", Translations: map[string]string{"zh-CN": "首领", "en": "Boss"}},
+		{Id: 6, Name: "music", Translations: map[string]string{"zh-CN": "音乐"}},
+		{Id: 5, Name: "rpg", Translations: map[string]string{"en": "BOSS"}},
+	}
+	tests := []struct {
+		id      int64
+		wantErr string
+	}{
+		{1, "zh-CN 下的名称 #游戏 与标签 #game 重复"},
+		{2, "zh 下的名称 #游戏 与标签 #游戏 重复"},
+		{4, "en 下的名称 #Boss 与标签 #rpg 重复"},
+		{6, ""},
+		{5, "en 下的名称 #BOSS 与标签 #boss 重复"},
+		{99, ""},
+	}
+	for _, tt := range tests {
+		err := CheckLocalizedTagNames(tags, tt.id)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("CheckLocalizedTagNames(%d) unexpected error %v", tt.id, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("CheckLocalizedTagNames(%d) error = %v, want containing %q", tt.id, err, tt.wantErr)
+		}
+	}
+}
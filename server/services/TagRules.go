@@ -0,0 +1,171 @@
+/* 标签兼容规则服务：依赖（requires）与互斥（excludes）规则的冲突检查，以及生成标题时按规则确定需要一起加入的标签 */
+package services
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// 标签兼容规则类型
+const (
+	TagRuleRequires = "requires" // 使用标签时必须同时使用目标标签
+	TagRuleExcludes = "excludes" // 标签与目标标签不能同时使用（双向生效）
+)
+
+// 一条标签兼容规则，名称仅用于提示信息
+type TagRule struct {
+	TagId      int64
+	TagName    string
+	Kind       string
+	TargetId   int64
+	TargetName string
+}
+
+// CheckTagRules 检查规则集合中的依赖循环与矛盾：
+//   - 依赖循环：A 依赖 B，B 又（直接或间接）依赖 A
+//   - 矛盾：使用某个标签时必须同时使用的标签（含自身）之间存在互斥规则，该标签将永远无法使用
+func CheckTagRules(rules []TagRule) error {
+	names := make(map[int64]string)
+	requires := make(map[int64][]int64)
+	excludes := make(map[int64][]int64)
+	ids := make([]int64, 0)
+	for _, rule := range rules {
+		names[rule.TagId] = "#" + rule.TagName
+		names[rule.TargetId] = "#" + rule.TargetName
+		if rule.Kind == TagRuleRequires {
+			requires[rule.TagId] = append(requires[rule.TagId], rule.TargetId)
+		} else {
+			excludes[rule.TagId] = append(excludes[rule.TagId], rule.TargetId)
+			excludes[rule.TargetId] = append(excludes[rule.TargetId], rule.TagId)
+		}
+		ids = append(ids, rule.TagId)
+	}
+	slices.Sort(ids)
+	ids = slices.Compact(ids)
+
+	// 深度优先查找依赖循环，path 为当前搜索路径
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[int64]int)
+	var path []int64
+	var findCycle func(id int64) []int64
+	findCycle = func(id int64) []int64 {
+		state[id] = visiting
+		path = append(path, id)
+		for _, next := range requires[id] {
+			if state[next] == visiting {
+				return append(slices.Clone(path[slices.Index(path, next):]), next)
+			}
+			if state[next] == 0 {
+				if cycle := findCycle(next); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[id] = visited
+		return nil
+	}
+	for _, id := range ids {
+		if state[id] != 0 {
+			continue
+		}
+		if cycle := findCycle(id); cycle != nil {
+			cycleNames := make([]string, 0, len(cycle))
+			for _, cid := range cycle {
+				cycleNames = append(cycleNames, names[cid])
+			}
+			return fmt.Errorf("标签依赖规则存在循环：%s", strings.Join(cycleNames, " → "))
+		}
+	}
+
+	// 检查每个标签依赖闭包内的互斥
+	for _, id := range ids {
+		closure := []int64{id}
+		for i := 0; i < len(closure); i++ {
+			for _, next := range requires[closure[i]] {
+				if !slices.Contains(closure, next) {
+					closure = append(closure, next)
+				}
+			}
+		}
+		for _, a := range closure {
+			for _, b := range excludes[a] {
+				if !slices.Contains(closure, b) {
+					continue
+				}
+				if a == id {
+					return fmt.Errorf("标签规则矛盾：%s 依赖 %s，但两者互斥", names[id], names[b])
+				}
+				if b == id {
+					return fmt.Errorf("标签规则矛盾：%s 依赖 %s，但两者互斥", names[id], names[a])
+				}
+				return fmt.Errorf("标签规则矛盾：使用 %s 时必须同时使用 %s 和 %s，但两者互斥", names[id], names[a], names[b])
+			}
+		}
+	}
+	return nil
+}
+
+// 生成标题时使用的标签兼容规则
+type TagRules struct {
+	requires map[int64][]int64
+	excludes map[int64][]int64 // 互斥规则双向记录
+	names    map[int64]string
+}
+
+func NewTagRules(rules []TagRule) *TagRules {
+	tr := &TagRules{
+		requires: make(map[int64][]int64),
+		excludes: make(map[int64][]int64),
+		names:    make(map[int64]string),
+	}
+	for _, rule := range rules {
+		tr.names[rule.TagId] = rule.TagName
+		tr.names[rule.TargetId] = rule.TargetName
+		switch rule.Kind {
+		case TagRuleRequires:
+			tr.requires[rule.TagId] = append(tr.requires[rule.TagId], rule.TargetId)
+		case TagRuleExcludes:
+			tr.excludes[rule.TagId] = append(tr.excludes[rule.TagId], rule.TargetId)
+			tr.excludes[rule.TargetId] = append(tr.excludes[rule.TargetId], rule.TagId)
+		}
+	}
+	return tr
+}
+
+// 计算使用tag时需要一起加入的标签（tag本身在首位，其后为尚未选中的依赖标签），依赖标签从available中查找。
+// 依赖的标签不可用，或者与已选中、将一起加入的标签互斥时，返回舍弃的原因。tr 为 nil 时表示没有规则
+func (tr *TagRules) resolve(tag TagCandidate, available []TagCandidate, pickedIds map[int64]bool) ([]TagCandidate, string) {
+	adding := []TagCandidate{tag}
+	if tr == nil {
+		return adding, ""
+	}
+	addingIds := map[int64]bool{tag.Id: true}
+	for i := 0; i < len(adding); i++ {
+		for _, requiredId := range tr.requires[adding[i].Id] {
+			if pickedIds[requiredId] || addingIds[requiredId] {
+				continue
+			}
+			index := slices.IndexFunc(available, func(t TagCandidate) bool {
+				return t.Id == requiredId
+			})
+			if index < 0 {
+				return nil, fmt.Sprintf("依赖的标签 #%s 不可用", tr.names[requiredId])
+			}
+			adding = append(adding, available[index])
+			addingIds[requiredId] = true
+		}
+	}
+	for _, t := range adding {
+		for _, excludedId := range tr.excludes[t.Id] {
+			if pickedIds[excludedId] || addingIds[excludedId] {
+				return nil, fmt.Sprintf("与标签 #%s 互斥", tr.names[excludedId])
+			}
+		}
+	}
+	return adding, ""
+}
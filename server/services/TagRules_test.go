@@ -0,0 +1,43 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckTagRules(t *testing.T) {
+	requires := func(tagId, targetId int64) TagRule {
+		return TagRule{TagId: tagId, TagName: string(rune('a' + tagId - 1)), Kind: TagRuleRequires, TargetId: targetId, TargetName: string(rune('a' + targetId - 1))}
+	}
+	excludes := func(tagId, targetId int64) TagRule {
+		rule := requires(tagId, targetId)
+		rule.Kind = TagRuleExcludes
+		return rule
+	}
+	tests := []struct {
+		name    string
+		rules   []TagRule
+		wantErr string
+	}{
+		{"empty", nil, ""},
+		{"chain", []TagRule{requires(1, 2), requires(2, 3), excludes(1, 4)}, ""},
+		{"self cycle", []TagRule{requires(1, 1)}, "存在循环：#a → #a"},
+		{"two tag cycle", []TagRule{requires(1, 2), requires(2, 1)}, "存在循环：#a → #b → #a"},
+		{"indirect cycle", []TagRule{requires(1, 2), requires(2, 3), requires(3, 1)}, "存在循环：#a → #b → #c → #a"},
+		{"requires excluded", []TagRule{requires(1, 2), excludes(2, 1)}, "#a 依赖 #b，但两者互斥"},
+		{"excluded by target", []TagRule{requires(1, 2), excludes(1, 2)}, "#a 依赖 #b，但两者互斥"},
+		{"indirect contradiction", []TagRule{requires(1, 2), requires(1, 3), excludes(2, 3)}, "使用 #a 时必须同时使用 #b 和 #c"},
+	}
+	for _, tt := range tests {
+		err := CheckTagRules(tt.rules)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error %v", tt.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: error = %v, want containing %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
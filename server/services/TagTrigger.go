@@ -0,0 +1,101 @@
+/* 标签触发词服务：标签可以设置若干触发词，主题命中触发词时优先使用该标签 */
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// 每个标签最多的触发词数量及单个触发词的最大字符数
+const (
+	MaxTagTriggers      = 20
+	MaxTagTriggerLength = 100
+)
+
+// 判断触发词是否为正则表达式，正则表达式写作 /pattern/
+func isTriggerRegexp(trigger string) bool {
+	return len(trigger) > 2 && strings.HasPrefix(trigger, "/") && strings.HasSuffix(trigger, "/")
+}
+
+// 规范化用于关键词匹配的文本：NFKC 规范化、转换为小写并将连续空白合并为一个空格
+func normalizeTriggerText(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(norm.NFKC.String(s))), " ")
+}
+
+// NormalizeTriggers 规范化并校验标签的触发词：
+//   - 普通关键词按 NFKC 规范化、转换为小写，并合并连续空白，匹配时不区分大小写
+//   - 写作 /pattern/ 的触发词作为正则表达式，匹配时不区分大小写，必须能够编译
+//
+// 空触发词被忽略，重复的触发词只保留一个。校验失败时返回 ValidationErrors
+func NormalizeTriggers(triggers []string) ([]string, error) {
+	var errs ValidationErrors
+	normalized := make([]string, 0, len(triggers))
+	for _, trigger := range triggers {
+		trigger = strings.TrimSpace(trigger)
+		if isTriggerRegexp(trigger) {
+			if strings.ContainsAny(trigger, "\r\n") {
+				errs = append(errs, FieldError{Field: "triggers", Message: fmt.Sprintf("触发词 %s 不能包含换行", trigger)})
+				continue
+			}
+			if _, err := regexp.Compile(trigger[1 : len(trigger)-1]); err != nil {
+				errs = append(errs, FieldError{Field: "triggers", Message: fmt.Sprintf("触发词 %s 不是有效的正则表达式", trigger)})
+				continue
+			}
+		} else {
+			trigger = normalizeTriggerText(trigger)
+		}
+		if trigger == "" || slices.Contains(normalized, trigger) {
+			continue
+		}
+		if utf8.RuneCountInString(trigger) > MaxTagTriggerLength {
+			errs = append(errs, FieldError{Field: "triggers", Message: fmt.Sprintf("单个触发词不能超过%d个字符", MaxTagTriggerLength)})
+			continue
+		}
+		normalized = append(normalized, trigger)
+	}
+	if len(normalized) > MaxTagTriggers {
+		errs = append(errs, FieldError{Field: "triggers", Message: fmt.Sprintf("每个标签最多设置%d个触发词", MaxTagTriggers)})
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return normalized, nil
+}
+
+// MatchedTriggerKeywords 返回主题命中的普通关键词触发词（不含正则表达式），用作 YouTube 标签字段中的短语
+func MatchedTriggerKeywords(theme string, triggers []string) []string {
+	text := normalizeTriggerText(theme)
+	keywords := make([]string, 0)
+	for _, trigger := range triggers {
+		if !isTriggerRegexp(trigger) && strings.Contains(text, trigger) {
+			keywords = append(keywords, trigger)
+		}
+	}
+	return keywords
+}
+
+// MatchTriggers 判断主题是否命中任意一个触发词。触发词应已经过 NormalizeTriggers 规范化，无法编译的正则表达式被忽略
+func MatchTriggers(theme string, triggers []string) bool {
+	if len(triggers) == 0 {
+		return false
+	}
+	text := normalizeTriggerText(theme)
+	for _, trigger := range triggers {
+		if isTriggerRegexp(trigger) {
+			re, err := regexp.Compile("(?i)" + trigger[1:len(trigger)-1])
+			if err == nil && re.MatchString(norm.NFKC.String(theme)) {
+				return true
+			}
+			continue
+		}
+		if strings.Contains(text, trigger) {
+			return true
+		}
+	}
+	return false
+}
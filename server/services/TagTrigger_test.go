@@ -0,0 +1,72 @@
+package services
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeTriggers(t *testing.T) {
+	tests := []struct {
+		name     string
+		triggers []string
+		want     []string
+		wantErr  string
+	}{
+		{"keywords", []string{"  Boss   Fight ", "ＢＯＳＳ fight", "", "Raid"}, []string{"boss fight", "raid"}, ""},
+		{"regexp kept as is", []string{"/Boss\\s+\\d+/", "/Boss\\s+\\d+/"}, []string{"/Boss\\s+\\d+/"}, ""},
+		{"invalid regexp", []string{"/boss(/"}, nil, "不是有效的正则表达式"},
+		{"slashes only", []string{"//"}, []string{"//"}, ""},
+		{"too long", []string{strings.Repeat("a", MaxTagTriggerLength+1)}, nil, "单个触发词不能超过"},
+		{"too many", func() []string {
+			triggers := make([]string, 0, MaxTagTriggers+1)
+			for i := range MaxTagTriggers + 1 {
+				triggers = append(triggers, strings.Repeat("x", i+1))
+			}
+			return triggers
+		}(), nil, "最多设置"},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeTriggers(tt.triggers)
+		if tt.wantErr != "" {
+			var validationErrors ValidationErrors
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) || !errors.As(err, &validationErrors) {
+				t.Errorf("%s: error = %v, want ValidationErrors containing %q", tt.name, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || !slices.Equal(got, tt.want) {
+			t.Errorf("%s: NormalizeTriggers = %q, %v, want %q", tt.name, got, err, tt.want)
+		}
+	}
+}
+
+func TestMatchTriggers(t *testing.T) {
+	tests := []struct {
+		theme    string
+		triggers []string
+		want     bool
+	}{
+		{"Final BOSS   Fight tonight", []string{"boss fight"}, true},
+		{"ＢＯＳＳ ＦＩＧＨＴ", []string{"boss fight"}, true},
+		{"Bossfight", []string{"boss fight"}, false},
+		{"Boss 42 cleared", []string{"/boss\\s+\\d+/"}, true},
+		{"BOSS 42 cleared", []string{"/boss\\s+\\d+/"}, true},
+		{"Boss cleared", []string{"/boss\\s+\\d+/"}, false},
+		{"boss(", []string{"/boss(/"}, false},
+		{"anything", nil, false},
+	}
+	for _, tt := range tests {
+		if got := MatchTriggers(tt.theme, tt.triggers); got != tt.want {
+			t.Errorf("MatchTriggers(%q, %q) = %v, want %v", tt.theme, tt.triggers, got, tt.want)
+		}
+	}
+}
+
+func TestMatchedTriggerKeywords(t *testing.T) {
+	got := MatchedTriggerKeywords("Final Boss Fight in Elden Ring", []string{"boss fight", "/elden/", "elden ring", "raid"})
+	if want := []string{"boss fight", "elden ring"}; !slices.Equal(got, want) {
+		t.Errorf("MatchedTriggerKeywords = %q, want %q", got, want)
+	}
+}
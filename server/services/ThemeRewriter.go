@@ -0,0 +1,145 @@
+/* 主题改写服务：调用兼容 OpenAI 接口的对话模型（/chat/completions），将视频主题改写为多个不同的说法 */
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 改写请求的默认超时时间
+const DefaultRewriteTimeout = 10 * time.Second
+
+// 主题改写的配置
+type RewriteConfig struct {
+	BaseURL string        // 接口地址，如 http://localhost:11434/v1，请求发送到 BaseURL + /chat/completions
+	Model   string        // 模型名称
+	APIKey  string        // 可选，设置后以 Bearer 方式发送
+	Timeout time.Duration // 单次改写的超时时间
+}
+
+// RewriteConfigFromEnv 从环境变量读取改写配置：
+// AI_REWRITE_BASE_URL、AI_REWRITE_MODEL、AI_REWRITE_API_KEY，以及 AI_REWRITE_TIMEOUT（如 10s，也可以是秒数）。
+// 未设置 AI_REWRITE_BASE_URL 时返回 false，表示不启用改写
+func RewriteConfigFromEnv() (RewriteConfig, bool) {
+	config := RewriteConfig{
+		BaseURL: strings.TrimRight(strings.TrimSpace(os.Getenv("AI_REWRITE_BASE_URL")), "/"),
+		Model:   strings.TrimSpace(os.Getenv("AI_REWRITE_MODEL")),
+		APIKey:  strings.TrimSpace(os.Getenv("AI_REWRITE_API_KEY")),
+		Timeout: DefaultRewriteTimeout,
+	}
+	if timeout := strings.TrimSpace(os.Getenv("AI_REWRITE_TIMEOUT")); timeout != "" {
+		if d, err := time.ParseDuration(timeout); err == nil && d > 0 {
+			config.Timeout = d
+		} else if seconds, err := strconv.Atoi(timeout); err == nil && seconds > 0 {
+			config.Timeout = time.Duration(seconds) * time.Second
+		}
+	}
+	return config, config.BaseURL != ""
+}
+
+// 主题改写器
+type ThemeRewriter struct {
+	config RewriteConfig
+	client *http.Client
+}
+
+func NewThemeRewriter(config RewriteConfig) *ThemeRewriter {
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultRewriteTimeout
+	}
+	return &ThemeRewriter{config: config, client: &http.Client{Timeout: config.Timeout}}
+}
+
+// 对话接口的请求与响应，只包含用到的字段
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatCompletionRequest struct {
+	Model    string        `json:"model,omitempty"`
+	Messages []chatMessage `json:"messages"`
+}
+
+type chatCompletionResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+}
+
+// Rewrite 将主题改写为最多count个不同的说法，每个不超过maxLength个字符（由模型遵守，调用方仍需校验）。
+// 超时或接口返回错误时返回错误，调用方应按原主题生成
+func (r *ThemeRewriter) Rewrite(theme string, count int, maxLength int) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), r.config.Timeout)
+	defer cancel()
+	body, err := json.Marshal(chatCompletionRequest{
+		Model: r.config.Model,
+		Messages: []chatMessage{
+			{Role: "system", Content: fmt.Sprintf("你是视频标题编辑。将用户给出的视频主题改写为%d个不同的说法，保持原意，使用与原主题相同的语言，"+
+				"每个不超过%d个字符，不要添加话题标签（#）。每行输出一个，不要编号，不要输出其他内容。", count, maxLength)},
+			{Role: "user", Content: theme},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.config.BaseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if r.config.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+r.config.APIKey)
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("改写接口返回 %d：%s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	var completion chatCompletionResponse
+	if err := json.NewDecoder(resp.Body).Decode(&completion); err != nil {
+		return nil, fmt.Errorf("解析改写接口响应失败：%w", err)
+	}
+	if len(completion.Choices) == 0 {
+		return nil, errors.New("改写接口没有返回结果")
+	}
+	return parseRewrittenThemes(completion.Choices[0].Message.Content, theme, count), nil
+}
+
+// 行首的编号或列表符号，如“1.”、“2、”、“3)”、“-”
+var rewriteListMarker = regexp.MustCompile(`^\s*(?:\d+[.)]\s+|\d+[、）]\s*|[-*•]\s+)`)
+
+// 从模型的回复中解析改写后的主题：每行一个，去掉编号、列表符号及引号，
+// 舍弃空行、包含话题标签的行及与原主题或其他结果相同的行，最多返回count个
+func parseRewrittenThemes(content string, theme string, count int) []string {
+	themes := make([]string, 0, count)
+	seen := map[string]bool{strings.TrimSpace(theme): true}
+	for line := range strings.SplitSeq(content, "\n") {
+		line = rewriteListMarker.ReplaceAllString(line, "")
+		line = strings.Trim(strings.TrimSpace(line), `"'“”「」`)
+		line = strings.TrimSpace(line)
+		if line == "" || strings.Contains(line, "#") || seen[line] {
+			continue
+		}
+		seen[line] = true
+		themes = append(themes, line)
+		if len(themes) == count {
+			break
+		}
+	}
+	return themes
+}
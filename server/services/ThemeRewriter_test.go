@@ -0,0 +1,95 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 返回固定回复的对话接口
+func newRewriteServer(t *testing.T, status int, content string, delay time.Duration) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("Authorization = %q, want %q", got, "Bearer secret")
+		}
+		var request chatCompletionRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.Model != "test-model" || len(request.Messages) != 2 {
+			t.Errorf("unexpected request body %+v, %v", request, err)
+		}
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+		w.WriteHeader(status)
+		if status != http.StatusOK {
+			w.Write([]byte(content))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"choices": []map[string]any{{"message": chatMessage{Role: "assistant", Content: content}}},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestThemeRewriterRewrite(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		content string
+		delay   time.Duration
+		want    []string
+		wantErr string
+	}{
+		{"success", http.StatusOK, "1. Boss Fight Showdown\n2) Final Boss Battle\n- Epic Boss Fight", 0, []string{"Boss Fight Showdown", "Final Boss Battle"}, ""},
+		{"non-200", http.StatusInternalServerError, "boom", 0, nil, "改写接口返回 500：boom"},
+		{"timeout", http.StatusOK, "1. Too Late", 500 * time.Millisecond, nil, "deadline exceeded"},
+	}
+	for _, tt := range tests {
+		server := newRewriteServer(t, tt.status, tt.content, tt.delay)
+		rewriter := NewThemeRewriter(RewriteConfig{BaseURL: server.URL + "/v1", Model: "test-model", APIKey: "secret", Timeout: 100 * time.Millisecond})
+		got, err := rewriter.Rewrite("Boss Fight", 2, 50)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: error = %v, want containing %q", tt.name, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || !slices.Equal(got, tt.want) {
+			t.Errorf("%s: Rewrite = %q, %v, want %q", tt.name, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseRewrittenThemes(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		count   int
+		want    []string
+	}{
+		{"plain lines", "Final Boss Battle\n\nEpic Boss Fight\n", 5, []string{"Final Boss Battle", "Epic Boss Fight"}},
+		{"numbered", "1. Final Boss Battle\n2) Epic Boss Fight\n10. Boss Rush", 5, []string{"Final Boss Battle", "Epic Boss Fight", "Boss Rush"}},
+		{"chinese markers", "1、最终决战\n2）史诗首领战", 5, []string{"最终决战", "史诗首领战"}},
+		{"bullets", "- Final Boss Battle\n* Epic Boss Fight\n• Boss Rush", 5, []string{"Final Boss Battle", "Epic Boss Fight", "Boss Rush"}},
+		{"quotes", "1. \"Final Boss Battle\"\n2. “Epic Boss Fight”\n3. 「最终决战」", 5, []string{"Final Boss Battle", "Epic Boss Fight", "最终决战"}},
+		{"number kept without marker", "2024 Boss Fight Recap", 5, []string{"2024 Boss Fight Recap"}},
+		{"drops original, duplicates and hashtags", "Boss Fight\nEpic Boss Fight\nEpic Boss Fight\nBoss #gaming", 5, []string{"Epic Boss Fight"}},
+		{"count limit", "a\nb\nc", 2, []string{"a", "b"}},
+	}
+	for _, tt := range tests {
+		if got := parseRewrittenThemes(tt.content, "Boss Fight", tt.count); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: parseRewrittenThemes = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
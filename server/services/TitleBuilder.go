@@ -0,0 +1,302 @@
+/* 标题生成服务：按频道的标签、配额、兼容规则及标签选择策略，为主题生成带话题标签的标题。不依赖 gin 与数据库，数据由调用方准备 */
+package services
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"strings"
+)
+
+// 每个候选标题的最大尝试次数，超过后认为标签组合已耗尽
+const maxAttemptsPerTitle = 20
+
+// 参与生成的标签
+type TagCandidate struct {
+	Id        int64
+	Name      string
+	Weight    int
+	GroupId   int64
+	Triggered bool // 主题命中了该标签的触发词
+}
+
+// 标签分组配额：生成的标题中该分组的标签数量需在 [Min, Max] 之间，Max 为0表示不限制
+type GroupQuota struct {
+	GroupId int64
+	Min     int
+	Max     int
+}
+
+// 生成标题所需的全部输入
+type TitleSpec struct {
+	Render     func(hashtags string) string // 将话题标签渲染为完整标题
+	Measurer   LengthMeasurer
+	Pinned     []TagCandidate // 置顶标签，按置顶顺序排列
+	Candidates []TagCandidate // 其他候选标签，调用方应保证顺序稳定（如按名称排序），相同的种子才能得到相同的结果
+	Quotas     []GroupQuota
+	Rules      *TagRules // 为 nil 时表示没有兼容规则
+	Strategy   string    // 标签选择策略名称，为空时按权重随机选择
+	Separator  string    // 话题标签之间的分隔符，为空时使用一个空格
+	Limits     TitleLimits
+}
+
+// 将话题标签按分隔符连接
+func (spec *TitleSpec) joinHashtags(hashtags []string) string {
+	if spec.Separator == "" {
+		return strings.Join(hashtags, " ")
+	}
+	return strings.Join(hashtags, spec.Separator)
+}
+
+// 生成的一个标题及其选中的标签名
+type GeneratedTitle struct {
+	Title string
+	Tags  []string
+}
+
+// 生成过程中未被选中的标签及原因
+type DroppedTag struct {
+	Name   string
+	Reason string
+}
+
+// 一个标题的生成过程：选中的标签及未被选中的候选标签
+type TitleExplanation struct {
+	Title   string
+	Picked  []string
+	Dropped []DroppedTag
+}
+
+// 检查标签能否满足各分组的最少数量，以及不带话题标签的标题是否超出长度限制，返回标签选择策略
+func (spec *TitleSpec) check() (TitleStrategy, error) {
+	strategy, err := NewTitleStrategy(spec.Strategy, spec.Measurer)
+	if err != nil {
+		return nil, err
+	}
+	for _, quota := range spec.Quotas {
+		available := 0
+		for _, tag := range slices.Concat(spec.Pinned, spec.Candidates) {
+			if tag.GroupId == quota.GroupId {
+				available++
+			}
+		}
+		if available < quota.Min {
+			return nil, fmt.Errorf("标签分组（ID：%d）至少需要%d个标签，但频道只关联了%d个", quota.GroupId, quota.Min, available)
+		}
+	}
+	if maxLength := spec.Limits.TitleLength(); spec.Measurer.Measure(spec.Render("")) > maxLength {
+		return nil, fmt.Errorf("按频道模板渲染后的标题长度不能超过%d个字符", maxLength)
+	}
+	return strategy, nil
+}
+
+// GenerateTitles 使用种子 seed 生成最多 count 个标签组合互不相同的标题。
+// 相同的 spec 与种子总是得到相同的结果；标签组合耗尽时返回的标题数量少于 count
+func GenerateTitles(spec *TitleSpec, count int, seed int64) ([]GeneratedTitle, error) {
+	strategy, err := spec.check()
+	if err != nil {
+		return nil, err
+	}
+
+	rng := rand.New(rand.NewSource(seed))
+	titles := make([]GeneratedTitle, 0, count)
+	seen := make(map[string]bool)
+	for attempt := 0; attempt < count*maxAttemptsPerTitle && len(titles) < count; attempt++ {
+		title, picked, err := buildTitle(spec, strategy, rng, nil)
+		if err != nil {
+			return nil, err
+		}
+		// 标签组合相同的候选只保留一个
+		key := strings.Join(slices.Sorted(slices.Values(picked)), ",")
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		titles = append(titles, GeneratedTitle{Title: title, Tags: picked})
+	}
+	return titles, nil
+}
+
+// ExplainTitle 说明使用种子 seed 生成的第一个标题（即 GenerateTitles 返回的第一个标题）如何选择标签，
+// 列出选中的标签，以及候选标签中未被选中的标签及原因
+func ExplainTitle(spec *TitleSpec, seed int64) (*TitleExplanation, error) {
+	strategy, err := spec.check()
+	if err != nil {
+		return nil, err
+	}
+	dropped := make([]DroppedTag, 0)
+	title, picked, err := buildTitle(spec, strategy, rand.New(rand.NewSource(seed)), func(tag TagCandidate, reason string) {
+		dropped = append(dropped, DroppedTag{Name: tag.Name, Reason: reason})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &TitleExplanation{Title: title, Picked: picked, Dropped: dropped}, nil
+}
+
+// 按策略不放回地抽取标签，交给render渲染成完整标题，直到按measurer计算的长度达到频道的标题长度限制，或话题标签达到数量限制。
+// 置顶标签总是按顺序排在最前，且不会因长度被舍弃；其次是主题命中触发词的标签，放不下时舍弃；
+// 频道设置了分组配额时，先为各分组选够最少数量的标签，再用剩余空间填充，且每个分组不超过最大数量；
+// 选中的标签须满足标签兼容规则：依赖的标签随之一起加入，与已选标签互斥的标签被舍弃。返回生成的标题及选中的标签名。
+// drop 不为 nil 时，每个未被选中的候选标签都会连同原因传给 drop，不影响抽样结果
+func buildTitle(spec *TitleSpec, strategy TitleStrategy, rng *rand.Rand, drop func(tag TagCandidate, reason string)) (string, []string, error) {
+	if drop == nil {
+		drop = func(TagCandidate, string) {}
+	}
+	render, measurer, pinned, quotas := spec.Render, spec.Measurer, spec.Pinned, spec.Quotas
+	maxLength := spec.Limits.TitleLength()
+	// 标签因长度放不下、因话题标签数量达到上限而被舍弃的原因
+	reasonTooLong := fmt.Sprintf("超出%d个字符的长度限制", maxLength)
+	reasonTooMany := fmt.Sprintf("超出%d个话题标签的数量限制", spec.Limits.MaxHashtags)
+	finalTitle := render("")
+	// 复制一份候选标签，避免抽样时修改调用方的切片。置顶标签也放入其中，以便作为其他标签的依赖被一起加入
+	needTags := slices.Concat(pinned, spec.Candidates)
+	picked := make([]string, 0)
+	pickedIds := make(map[int64]bool)
+	hashtags := make([]string, 0)
+	groupCount := make(map[int64]int)
+	groupMax := make(map[int64]int)
+	for _, quota := range quotas {
+		groupMax[quota.GroupId] = quota.Max
+	}
+	// 从needTags中取出一个标签，连同其依赖的标签一起放入标题。
+	// 成功时返回空字符串，否则返回舍弃的原因，原因为 reasonTooLong 或 reasonTooMany 时表示放不下
+	take := func(index int) string {
+		tag := needTags[index]
+		// 从needTags中删除已选择的标签
+		needTags = append(needTags[:index], needTags[index+1:]...)
+		adding, reason := spec.Rules.resolve(tag, needTags, pickedIds)
+		if reason != "" {
+			drop(tag, reason)
+			return reason
+		}
+		addingCount := make(map[int64]int)
+		for _, t := range adding[1:] {
+			addingCount[t.GroupId]++
+		}
+		for groupId, n := range addingCount {
+			if max := groupMax[groupId]; max > 0 && groupCount[groupId]+n > max {
+				reason := fmt.Sprintf("依赖的标签超出分组（ID：%d）的最大数量", groupId)
+				drop(tag, reason)
+				return reason
+			}
+		}
+		if !spec.Limits.allowsHashtags(len(hashtags), len(adding)) {
+			drop(tag, reasonTooMany)
+			return reasonTooMany
+		}
+		addingHashtags := make([]string, 0, len(adding))
+		for _, t := range adding {
+			addingHashtags = append(addingHashtags, "#"+t.Name)
+		}
+		tmp := render(spec.joinHashtags(slices.Concat(hashtags, addingHashtags)))
+		if measurer.Measure(tmp) > maxLength {
+			drop(tag, reasonTooLong)
+			return reasonTooLong
+		}
+		finalTitle = tmp
+		hashtags = append(hashtags, addingHashtags...)
+		for _, t := range adding {
+			picked = append(picked, t.Name)
+			pickedIds[t.Id] = true
+			groupCount[t.GroupId]++
+		}
+		// 依赖的标签已经加入，从needTags中删除
+		needTags = slices.DeleteFunc(needTags, func(t TagCandidate) bool {
+			return pickedIds[t.Id]
+		})
+		return ""
+	}
+
+	// 判断标签单独放入当前标题后是否超出长度限制，供策略参考
+	fits := func(tag TagCandidate) bool {
+		return measurer.Measure(render(spec.joinHashtags(append(slices.Clone(hashtags), "#"+tag.Name)))) <= maxLength
+	}
+
+	// 置顶标签必须全部放入
+	for _, tag := range pinned {
+		index := slices.IndexFunc(needTags, func(t TagCandidate) bool {
+			return t.Id == tag.Id
+		})
+		if index < 0 {
+			// 已作为其他置顶标签的依赖加入
+			continue
+		}
+		switch reason := take(index); reason {
+		case "":
+		case reasonTooLong:
+			return "", nil, fmt.Errorf("置顶标签的总长度超过%d个字符，请减少置顶标签或缩短主题", maxLength)
+		case reasonTooMany:
+			return "", nil, fmt.Errorf("置顶标签的数量超过%d个话题标签的限制，请减少置顶标签", spec.Limits.MaxHashtags)
+		default:
+			return "", nil, fmt.Errorf("置顶标签 #%s 无法使用：%s", tag.Name, reason)
+		}
+	}
+
+	// 主题命中触发词的标签按名称顺序优先放入，不消耗随机数，保证种子的可复现性
+	for i := 0; i < len(needTags); {
+		tag := needTags[i]
+		if !tag.Triggered {
+			i++
+			continue
+		}
+		if max := groupMax[tag.GroupId]; max > 0 && groupCount[tag.GroupId] >= max {
+			i++
+			continue
+		}
+		// take 会从needTags中删除该标签（及其依赖），从头重新查找
+		take(i)
+		i = 0
+	}
+
+	// 再满足各分组的最少数量
+	for _, quota := range quotas {
+		for groupCount[quota.GroupId] < quota.Min {
+			pool := make([]TagCandidate, 0)
+			poolIndexes := make([]int, 0)
+			for i, tag := range needTags {
+				if tag.GroupId == quota.GroupId {
+					pool = append(pool, tag)
+					poolIndexes = append(poolIndexes, i)
+				}
+			}
+			if len(pool) == 0 {
+				return "", nil, fmt.Errorf("无法在标题长度及话题标签数量限制内满足标签分组（ID：%d）至少%d个标签的配额", quota.GroupId, quota.Min)
+			}
+			take(poolIndexes[strategy.Pick(pool, fits, rng)])
+		}
+	}
+
+	// 再用剩余空间按策略填充
+	for measurer.Measure(finalTitle) < maxLength && spec.Limits.allowsHashtags(len(hashtags), 1) && len(needTags) > 0 {
+		// 按策略从needTags中选择一个标签（不放回抽样）
+		tmpIndex := strategy.Pick(needTags, fits, rng)
+		tag := needTags[tmpIndex]
+		if max := groupMax[tag.GroupId]; max > 0 && groupCount[tag.GroupId] >= max {
+			// 该分组已达到最大数量，跳过
+			drop(tag, fmt.Sprintf("已达到分组（ID：%d）的最大数量", tag.GroupId))
+			needTags = append(needTags[:tmpIndex], needTags[tmpIndex+1:]...)
+			continue
+		}
+		// 放不下时结束填充，因规则被舍弃时继续抽取下一个
+		if reason := take(tmpIndex); reason == reasonTooLong || reason == reasonTooMany {
+			break
+		}
+	}
+	// 填充结束后仍未抽取的标签
+	for _, tag := range needTags {
+		if _, reason := spec.Rules.resolve(tag, needTags, pickedIds); reason != "" {
+			drop(tag, reason)
+		} else if !spec.Limits.allowsHashtags(len(hashtags), 1) {
+			drop(tag, reasonTooMany)
+		} else if fits(tag) {
+			drop(tag, reasonNotDrawn)
+		} else {
+			drop(tag, reasonTooLong)
+		}
+	}
+	return finalTitle, picked, nil
+}
+
+// 标签长度允许，但填充结束前未被策略选中的原因
+const reasonNotDrawn = "未被抽中，标题填充已结束"
@@ -0,0 +1,183 @@
+package services
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// 测试用的标题规格：主题 + 空格 + 话题标签
+func newTestSpec(theme string, candidates []TagCandidate) *TitleSpec {
+	measurer, _ := NewLengthMeasurer("")
+	return &TitleSpec{
+		Render: func(hashtags string) string {
+			return strings.TrimSpace(theme + " " + hashtags)
+		},
+		Measurer:   measurer,
+		Candidates: candidates,
+	}
+}
+
+func testCandidates(names ...string) []TagCandidate {
+	candidates := make([]TagCandidate, 0, len(names))
+	for i, name := range names {
+		candidates = append(candidates, TagCandidate{Id: int64(i + 1), Name: name, Weight: 1, GroupId: 1})
+	}
+	return candidates
+}
+
+func TestGenerateTitlesDeterministic(t *testing.T) {
+	candidates := testCandidates("alpha", "beta", "gamma", "delta", "epsilon", "zeta", "eta", "theta")
+	for _, strategy := range []string{TitleStrategyWeighted, TitleStrategyRandom, TitleStrategyRoundRobin, TitleStrategyGreedy} {
+		for _, seed := range []int64{1, 42, 1 << 40} {
+			spec := newTestSpec("theme", candidates)
+			spec.Strategy = strategy
+			spec.Limits = TitleLimits{MaxTitleLength: 30}
+			first, err := GenerateTitles(spec, 5, seed)
+			if err != nil {
+				t.Fatalf("%s seed %d: %v", strategy, seed, err)
+			}
+			second, _ := GenerateTitles(spec, 5, seed)
+			if !reflect.DeepEqual(first, second) {
+				t.Errorf("%s seed %d: results differ between runs: %v, %v", strategy, seed, first, second)
+			}
+			explanation, err := ExplainTitle(spec, seed)
+			if err != nil {
+				t.Fatalf("%s seed %d: ExplainTitle: %v", strategy, seed, err)
+			}
+			if len(first) == 0 || explanation.Title != first[0].Title {
+				t.Errorf("%s seed %d: ExplainTitle = %q, want first generated title", strategy, seed, explanation.Title)
+			}
+			for _, title := range first {
+				if len([]rune(title.Title)) > 30 {
+					t.Errorf("%s seed %d: title %q exceeds 30 characters", strategy, seed, title.Title)
+				}
+			}
+		}
+	}
+}
+
+func TestGenerateTitlesGreedyFillsTitle(t *testing.T) {
+	spec := newTestSpec("theme", testCandidates("a", "bbbbbb", "ccc"))
+	spec.Strategy = TitleStrategyGreedy
+	spec.Limits = TitleLimits{MaxTitleLength: 20}
+	titles, err := GenerateTitles(spec, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "theme #bbbbbb #ccc"; len(titles) != 1 || titles[0].Title != want {
+		t.Errorf("greedy titles = %v, want %q", titles, want)
+	}
+}
+
+func TestGenerateTitlesPinnedAndLimits(t *testing.T) {
+	pinned := testCandidates("pinned")
+	tests := []struct {
+		name    string
+		spec    func() *TitleSpec
+		want    string // 期望生成的第一个标题，wantErr 不为空时忽略
+		wantErr string
+	}{
+		{
+			name: "pinned first",
+			spec: func() *TitleSpec {
+				spec := newTestSpec("theme", []TagCandidate{{Id: 2, Name: "other", Weight: 1}})
+				spec.Pinned = pinned
+				return spec
+			},
+			want: "theme #pinned #other",
+		},
+		{
+			name: "pinned too long",
+			spec: func() *TitleSpec {
+				spec := newTestSpec("theme", nil)
+				spec.Pinned = pinned
+				spec.Limits = TitleLimits{MaxTitleLength: 10}
+				return spec
+			},
+			wantErr: "置顶标签的总长度超过10个字符",
+		},
+		{
+			name: "pinned exceeds hashtag count",
+			spec: func() *TitleSpec {
+				spec := newTestSpec("theme", nil)
+				spec.Pinned = testCandidates("a", "b")
+				spec.Limits = TitleLimits{MaxHashtags: 1}
+				return spec
+			},
+			wantErr: "置顶标签的数量超过1个话题标签的限制",
+		},
+		{
+			name: "hashtag count limit",
+			spec: func() *TitleSpec {
+				spec := newTestSpec("theme", testCandidates("a", "b", "c", "d"))
+				spec.Strategy = TitleStrategyGreedy
+				spec.Limits = TitleLimits{MaxHashtags: 2}
+				return spec
+			},
+			want: "theme #a #b",
+		},
+		{
+			name: "theme too long",
+			spec: func() *TitleSpec {
+				spec := newTestSpec(strings.Repeat("x", 11), testCandidates("a"))
+				spec.Limits = TitleLimits{MaxTitleLength: 10}
+				return spec
+			},
+			wantErr: "标题长度不能超过10个字符",
+		},
+		{
+			name: "quota not enough tags",
+			spec: func() *TitleSpec {
+				spec := newTestSpec("theme", testCandidates("a"))
+				spec.Quotas = []GroupQuota{{GroupId: 1, Min: 2}}
+				return spec
+			},
+			wantErr: "至少需要2个标签",
+		},
+		{
+			name: "unknown strategy",
+			spec: func() *TitleSpec {
+				spec := newTestSpec("theme", testCandidates("a"))
+				spec.Strategy = "unknown"
+				return spec
+			},
+			wantErr: "不支持的标签选择策略",
+		},
+	}
+	for _, tt := range tests {
+		titles, err := GenerateTitles(tt.spec(), 1, 1)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: error = %v, want containing %q", tt.name, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if len(titles) == 0 || titles[0].Title != tt.want {
+			t.Errorf("%s: titles = %v, want %q", tt.name, titles, tt.want)
+		}
+	}
+}
+
+func TestGenerateTitlesRules(t *testing.T) {
+	candidates := testCandidates("a", "b", "c")
+	spec := newTestSpec("theme", candidates)
+	spec.Rules = NewTagRules([]TagRule{
+		{TagId: 1, TagName: "a", Kind: TagRuleRequires, TargetId: 2, TargetName: "b"},
+		{TagId: 1, TagName: "a", Kind: TagRuleExcludes, TargetId: 3, TargetName: "c"},
+	})
+	titles, err := GenerateTitles(spec, 10, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, title := range titles {
+		if slices.Contains(title.Tags, "a") && (!slices.Contains(title.Tags, "b") || slices.Contains(title.Tags, "c")) {
+			t.Errorf("title %q violates tag rules", title.Title)
+		}
+	}
+}
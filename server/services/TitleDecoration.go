@@ -0,0 +1,124 @@
+/* 频道标题装饰服务：标题前后缀、话题标签的分隔符、位置及大小写 */
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// 话题标签在标题中的位置
+const (
+	HashtagPlacementEnd              = "end"                // 主题之后（默认）
+	HashtagPlacementStart            = "start"              // 主题之前
+	HashtagPlacementAfterFirstClause = "after_first_clause" // 主题的第一个分句之后，主题没有分句时放在最后
+)
+
+// 话题标签的大小写
+const (
+	HashtagCaseAsIs  = "as_is" // 保持标签名不变
+	HashtagCaseLower = "lower" // 全部小写（默认，与标签名保留大小写之前的输出一致）
+	HashtagCaseCamel = "camel" // 每个以下划线分隔的单词首字母大写并去掉下划线，其余字母保持不变，如 boss_fight -> BossFight、MineCraft -> MineCraft
+)
+
+// 标题前后缀及话题标签分隔符的最大字符数
+const (
+	MaxTitleAffixLength       = 20
+	MaxHashtagSeparatorLength = 3
+)
+
+// 分句的结束符号
+const clauseDelimiters = ",，.。!！?？:：;；|｜"
+
+// 频道的标题装饰设置，空字符串表示使用默认值
+type TitleDecoration struct {
+	Prefix           string // 标题前缀，如“【Live】”
+	Suffix           string // 标题后缀
+	HashtagSeparator string // 话题标签之间的分隔符，默认为一个空格
+	HashtagPlacement string
+	HashtagCase      string
+}
+
+// Validate 校验装饰设置
+func (d TitleDecoration) Validate() error {
+	if utf8.RuneCountInString(d.Prefix) > MaxTitleAffixLength || utf8.RuneCountInString(d.Suffix) > MaxTitleAffixLength {
+		return fmt.Errorf("标题前缀、后缀不能超过%d个字符", MaxTitleAffixLength)
+	}
+	if utf8.RuneCountInString(d.HashtagSeparator) > MaxHashtagSeparatorLength {
+		return fmt.Errorf("话题标签分隔符不能超过%d个字符", MaxHashtagSeparatorLength)
+	}
+	if strings.Contains(d.HashtagSeparator, "#") {
+		return errors.New("话题标签分隔符不能包含#")
+	}
+	switch d.HashtagPlacement {
+	case "", HashtagPlacementEnd, HashtagPlacementStart, HashtagPlacementAfterFirstClause:
+	default:
+		return fmt.Errorf("不支持的话题标签位置：%s", d.HashtagPlacement)
+	}
+	switch d.HashtagCase {
+	case "", HashtagCaseAsIs, HashtagCaseLower, HashtagCaseCamel:
+	default:
+		return fmt.Errorf("不支持的话题标签大小写：%s", d.HashtagCase)
+	}
+	return nil
+}
+
+// Separator 返回话题标签之间的分隔符
+func (d TitleDecoration) Separator() string {
+	if d.HashtagSeparator == "" {
+		return " "
+	}
+	return d.HashtagSeparator
+}
+
+// ApplyCase 按设置的大小写转换标签名（不含#）
+func (d TitleDecoration) ApplyCase(name string) string {
+	switch d.HashtagCase {
+	case "", HashtagCaseLower:
+		return strings.ToLower(name)
+	case HashtagCaseCamel:
+		var b strings.Builder
+		for _, word := range strings.Split(name, "_") {
+			first, size := utf8.DecodeRuneInString(word)
+			if size == 0 {
+				continue
+			}
+			b.WriteRune(unicode.ToUpper(first))
+			b.WriteString(word[size:])
+		}
+		if b.Len() == 0 {
+			return name
+		}
+		return b.String()
+	default:
+		return name
+	}
+}
+
+// Decorate 将话题标签按设置的位置放入base，并加上前后缀。hashtags 为空时只加前后缀
+func (d TitleDecoration) Decorate(base, hashtags string) string {
+	if hashtags == "" {
+		return d.Prefix + base + d.Suffix
+	}
+	var title string
+	switch d.HashtagPlacement {
+	case HashtagPlacementStart:
+		title = hashtags + " " + base
+	case HashtagPlacementAfterFirstClause:
+		if i := strings.IndexAny(base, clauseDelimiters); i >= 0 {
+			_, size := utf8.DecodeRuneInString(base[i:])
+			head, rest := base[:i+size], strings.TrimSpace(base[i+size:])
+			title = head + " " + hashtags
+			if rest != "" {
+				title += " " + rest
+			}
+		} else {
+			title = base + " " + hashtags
+		}
+	default:
+		title = base + " " + hashtags
+	}
+	return d.Prefix + title + d.Suffix
+}
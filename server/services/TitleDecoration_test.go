@@ -0,0 +1,45 @@
+package services
+
+import "testing"
+
+func TestApplyCase(t *testing.T) {
+	tests := []struct {
+		hashtagCase string
+		name        string
+		want        string
+	}{
+		{HashtagCaseAsIs, "MineCraft", "MineCraft"},
+		{"", "MineCraft", "minecraft"},
+		{HashtagCaseLower, "MineCraft", "minecraft"},
+		{HashtagCaseCamel, "MineCraft", "MineCraft"},
+		{HashtagCaseCamel, "boss_fight", "BossFight"},
+		{HashtagCaseCamel, "minecraft", "Minecraft"},
+		{HashtagCaseCamel, "_", "_"},
+		{HashtagCaseCamel, "游戏", "游戏"},
+	}
+	for _, tt := range tests {
+		d := TitleDecoration{HashtagCase: tt.hashtagCase}
+		if got := d.ApplyCase(tt.name); got != tt.want {
+			t.Errorf("ApplyCase(%q) with %q = %q, want %q", tt.name, tt.hashtagCase, got, tt.want)
+		}
+	}
+}
+
+func TestDecorate(t *testing.T) {
+	tests := []struct {
+		placement string
+		base      string
+		want      string
+	}{
+		{HashtagPlacementEnd, "hello, world", "[A]hello, world #a #b!"},
+		{HashtagPlacementStart, "hello, world", "[A]#a #b hello, world!"},
+		{HashtagPlacementAfterFirstClause, "hello, world", "[A]hello, #a #b world!"},
+		{HashtagPlacementAfterFirstClause, "hello world", "[A]hello world #a #b!"},
+	}
+	for _, tt := range tests {
+		d := TitleDecoration{Prefix: "[A]", Suffix: "!", HashtagPlacement: tt.placement}
+		if got := d.Decorate(tt.base, "#a #b"); got != tt.want {
+			t.Errorf("Decorate(%q) with %q = %q, want %q", tt.base, tt.placement, got, tt.want)
+		}
+	}
+}
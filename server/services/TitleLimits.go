@@ -0,0 +1,61 @@
+/* 频道标题长度限制服务：标题长度、主题长度及话题标签数量的上限，长度按频道的长度计算方式计算 */
+package services
+
+import (
+	"errors"
+	"fmt"
+)
+
+// 默认的标题长度上限（YouTube 标题的长度限制）
+const DefaultMaxTitleLength = 100
+
+// 可设置的上限
+const (
+	MaxTitleLengthLimit  = 1000 // 标题及主题长度上限的最大值
+	MaxHashtagCountLimit = 60   // 话题标签数量上限的最大值，YouTube 会忽略超过60个话题标签的标题中的全部话题标签
+)
+
+// 频道的标题长度限制，0表示使用默认值
+type TitleLimits struct {
+	MaxTitleLength int // 标题的最大长度，默认为100
+	MaxThemeLength int // 主题的最大长度，默认与标题的最大长度相同
+	MaxHashtags    int // 话题标签的最大数量，默认不限制
+}
+
+// Validate 校验长度限制
+func (l TitleLimits) Validate() error {
+	if l.MaxTitleLength < 0 || l.MaxTitleLength > MaxTitleLengthLimit {
+		return fmt.Errorf("标题最大长度必须在1到%d之间，0表示使用默认值", MaxTitleLengthLimit)
+	}
+	if l.MaxThemeLength < 0 || l.MaxThemeLength > MaxTitleLengthLimit {
+		return fmt.Errorf("主题最大长度必须在1到%d之间，0表示使用默认值", MaxTitleLengthLimit)
+	}
+	if l.MaxThemeLength > l.TitleLength() {
+		return errors.New("主题最大长度不能超过标题最大长度")
+	}
+	if l.MaxHashtags < 0 || l.MaxHashtags > MaxHashtagCountLimit {
+		return fmt.Errorf("话题标签最大数量必须在1到%d之间，0表示不限制", MaxHashtagCountLimit)
+	}
+	return nil
+}
+
+// TitleLength 返回标题的最大长度
+func (l TitleLimits) TitleLength() int {
+	if l.MaxTitleLength == 0 {
+		return DefaultMaxTitleLength
+	}
+	return l.MaxTitleLength
+}
+
+// ThemeLength 返回主题的最大长度
+func (l TitleLimits) ThemeLength() int {
+	if l.MaxThemeLength == 0 {
+		return l.TitleLength()
+	}
+	return l.MaxThemeLength
+}
+
+// 判断在已有count个话题标签的标题中能否再加入n个
+func (l TitleLimits) allowsHashtags(count, n int) bool {
+	return l.MaxHashtags == 0 || count+n <= l.MaxHashtags
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTitleLimitsValidate(t *testing.T) {
+	tests := []struct {
+		limits  TitleLimits
+		wantErr string
+	}{
+		{TitleLimits{}, ""},
+		{TitleLimits{MaxTitleLength: 1, MaxThemeLength: 1, MaxHashtags: 1}, ""},
+		{TitleLimits{MaxTitleLength: MaxTitleLengthLimit, MaxThemeLength: MaxTitleLengthLimit, MaxHashtags: MaxHashtagCountLimit}, ""},
+		{TitleLimits{MaxTitleLength: -1}, "标题最大长度"},
+		{TitleLimits{MaxTitleLength: MaxTitleLengthLimit + 1}, "标题最大长度"},
+		{TitleLimits{MaxThemeLength: -1}, "主题最大长度必须"},
+		{TitleLimits{MaxThemeLength: MaxTitleLengthLimit + 1}, "主题最大长度必须"},
+		// 主题最大长度不能超过标题最大长度（包括默认的标题最大长度）
+		{TitleLimits{MaxTitleLength: 50, MaxThemeLength: 50}, ""},
+		{TitleLimits{MaxTitleLength: 50, MaxThemeLength: 51}, "主题最大长度不能超过标题最大长度"},
+		{TitleLimits{MaxThemeLength: DefaultMaxTitleLength}, ""},
+		{TitleLimits{MaxThemeLength: DefaultMaxTitleLength + 1}, "主题最大长度不能超过标题最大长度"},
+		{TitleLimits{MaxHashtags: -1}, "话题标签最大数量"},
+		{TitleLimits{MaxHashtags: MaxHashtagCountLimit + 1}, "话题标签最大数量"},
+	}
+	for _, tt := range tests {
+		err := tt.limits.Validate()
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("%+v.Validate() unexpected error %v", tt.limits, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%+v.Validate() error = %v, want containing %q", tt.limits, err, tt.wantErr)
+		}
+	}
+}
+
+func TestTitleLimitsDefaults(t *testing.T) {
+	tests := []struct {
+		limits      TitleLimits
+		titleLength int
+		themeLength int
+	}{
+		{TitleLimits{}, DefaultMaxTitleLength, DefaultMaxTitleLength},
+		{TitleLimits{MaxTitleLength: 60}, 60, 60},
+		{TitleLimits{MaxTitleLength: 60, MaxThemeLength: 40}, 60, 40},
+	}
+	for _, tt := range tests {
+		if got := tt.limits.TitleLength(); got != tt.titleLength {
+			t.Errorf("%+v.TitleLength() = %d, want %d", tt.limits, got, tt.titleLength)
+		}
+		if got := tt.limits.ThemeLength(); got != tt.themeLength {
+			t.Errorf("%+v.ThemeLength() = %d, want %d", tt.limits, got, tt.themeLength)
+		}
+	}
+}
+
+func TestTitleLimitsAllowsHashtags(t *testing.T) {
+	tests := []struct {
+		maxHashtags int
+		count, n    int
+		want        bool
+	}{
+		{0, 100, 1, true},
+		{3, 0, 3, true},
+		{3, 2, 1, true},
+		{3, 3, 1, false},
+		{3, 2, 2, false},
+		{1, 0, 1, true},
+		{1, 1, 0, true},
+	}
+	for _, tt := range tests {
+		if got := (TitleLimits{MaxHashtags: tt.maxHashtags}).allowsHashtags(tt.count, tt.n); got != tt.want {
+			t.Errorf("MaxHashtags %d allowsHashtags(%d, %d) = %v, want %v", tt.maxHashtags, tt.count, tt.n, got, tt.want)
+		}
+	}
+}
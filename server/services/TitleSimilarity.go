@@ -0,0 +1,136 @@
+/* 标题相似度服务：将新生成的标题与历史标题比较，找出完全相同及近似重复的标题 */
+package services
+
+import (
+	"cmp"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// 相似度达到该值时认为两个标题近似重复
+const DuplicateSimilarityThreshold = 0.85
+
+// 与历史标题的一次匹配
+type SimilarTitle struct {
+	Index      int     // 历史标题在参数中的下标
+	Similarity float64 // 0到1之间，1表示规范化后完全相同
+	Exact      bool    // 与历史标题完全相同（未经规范化）
+}
+
+// 标题中的话题标签，标签名的字符与 NormalizeHashtag 允许的字符一致
+var titleHashtag = regexp.MustCompile(`#[\p{L}\p{N}\p{M}_]+`)
+
+// 规范化用于比较的标题：NFKC 规范化、转换为小写并去掉话题标签，只比较主题等其余部分；
+// 去掉话题标签后为空时保留话题标签。标点、符号视为空白，并将连续空白合并为一个空格
+func normalizeTitleText(s string) string {
+	s = strings.ToLower(norm.NFKC.String(s))
+	if stripped := titleHashtag.ReplaceAllString(s, " "); strings.ContainsFunc(stripped, isTitleTextRune) {
+		s = stripped
+	}
+	s = strings.Map(func(r rune) rune {
+		if isTitleTextRune(r) {
+			return r
+		}
+		return ' '
+	}, s)
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// 参与比较的字符：字母、数字及组合符号
+func isTitleTextRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsNumber(r) || unicode.IsMark(r)
+}
+
+// 按字符计算的编辑距离相似度：1 - 编辑距离 / 较长文本的字符数
+func editSimilarity(a, b []rune) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+	// 只保留上一行，空间复杂度 O(len(b))
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return 1 - float64(prev[len(b)])/float64(max(len(a), len(b)))
+}
+
+// 按单词集合计算的 Jaccard 相似度，用于识别仅调整了单词顺序的标题
+func tokenSimilarity(a, b string) float64 {
+	tokensA := make(map[string]bool)
+	for _, token := range strings.Fields(a) {
+		tokensA[token] = true
+	}
+	tokensB := make(map[string]bool)
+	for _, token := range strings.Fields(b) {
+		tokensB[token] = true
+	}
+	if len(tokensA) == 0 && len(tokensB) == 0 {
+		return 1
+	}
+	common := 0
+	for token := range tokensA {
+		if tokensB[token] {
+			common++
+		}
+	}
+	return float64(common) / float64(len(tokensA)+len(tokensB)-common)
+}
+
+// TitleSimilarity 计算两个标题规范化后（不含话题标签）的相似度，取编辑距离相似度与单词相似度中较高的一个
+func TitleSimilarity(a, b string) float64 {
+	return normalizedSimilarity([]rune(normalizeTitleText(a)), []rune(normalizeTitleText(b)), 0)
+}
+
+// 计算两个已规范化文本的相似度。编辑距离的计算量为 O(len(a)×len(b))，
+// 先用字符数之差得到编辑距离相似度的上限，上限不超过单词相似度或floor时不再计算编辑距离，
+// 此时低于floor的结果只保证低于floor，不是准确值
+func normalizedSimilarity(a, b []rune, floor float64) float64 {
+	if slices.Equal(a, b) {
+		return 1
+	}
+	similarity := tokenSimilarity(string(a), string(b))
+	longer := max(len(a), len(b))
+	if bound := 1 - float64(longer-min(len(a), len(b)))/float64(longer); bound > max(similarity, floor) {
+		similarity = max(similarity, editSimilarity(a, b))
+	}
+	return similarity
+}
+
+// FindSimilarTitles 在previous中查找与title完全相同或相似度不低于threshold的标题，完全相同的在前，其余按相似度从高到低排列
+func FindSimilarTitles(title string, previous []string, threshold float64) []SimilarTitle {
+	matches := make([]SimilarTitle, 0)
+	normalized := []rune(normalizeTitleText(title))
+	for i, p := range previous {
+		// 只比较与 threshold 相关的部分：编辑距离相似度的上限低于 threshold 时不计算编辑距离
+		similarity := normalizedSimilarity(normalized, []rune(normalizeTitleText(p)), threshold)
+		if title == p || similarity >= threshold {
+			matches = append(matches, SimilarTitle{Index: i, Similarity: similarity, Exact: title == p})
+		}
+	}
+	slices.SortStableFunc(matches, func(a, b SimilarTitle) int {
+		if a.Exact != b.Exact {
+			if a.Exact {
+				return -1
+			}
+			return 1
+		}
+		return cmp.Compare(b.Similarity, a.Similarity)
+	})
+	return matches
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNormalizeTitleText(t *testing.T) {
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{"Boss Fight! #Gaming #MineCraft", "boss fight"},
+		{"ＢＯＳＳ｜Fight #游戏_实况", "boss fight"},
+		{"#Gaming #MineCraft", "gaming minecraft"},
+		{"Boss #1 Fight", "boss fight"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := normalizeTitleText(tt.title); got != tt.want {
+			t.Errorf("normalizeTitleText(%q) = %q, want %q", tt.title, got, tt.want)
+		}
+	}
+}
+
+func TestTitleSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"Boss Fight #gaming", "Boss Fight #minecraft #shorts", 1},
+		{"Boss Fight", "Fight Boss", 1},
+		{"abcd", "abce", 0.75},
+	}
+	for _, tt := range tests {
+		if got := TitleSimilarity(tt.a, tt.b); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("TitleSimilarity(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+	// 相同的话题标签不会让不同主题的标题被认为近似重复
+	a, b := "Boss Fight #gaming #shorts #minecraft", "Cooking Show #gaming #shorts #minecraft"
+	if got := TitleSimilarity(a, b); got >= DuplicateSimilarityThreshold {
+		t.Errorf("TitleSimilarity(%q, %q) = %v, want below %v", a, b, got, DuplicateSimilarityThreshold)
+	}
+}
+
+func TestFindSimilarTitles(t *testing.T) {
+	previous := []string{"Cooking Show #food", "Boss Fight Part 2 #gaming", "Boss Fight #gaming", "Boss Fight #minecraft"}
+	got := FindSimilarTitles("Boss Fight #gaming", previous, DuplicateSimilarityThreshold)
+	if len(got) != 2 || got[0].Index != 2 || !got[0].Exact || got[1].Index != 3 || got[1].Exact || got[1].Similarity != 1 {
+		t.Errorf("FindSimilarTitles = %+v, want exact match 2 followed by 3", got)
+	}
+}
+
+func TestNormalizedSimilarityFloor(t *testing.T) {
+	short, long := []rune("boss"), []rune("boss fight part two")
+	exact := max(editSimilarity(short, long), tokenSimilarity(string(short), string(long)))
+	if got := normalizedSimilarity(short, long, 0); got != exact {
+		t.Errorf("normalizedSimilarity without floor = %v, want %v", got, exact)
+	}
+	// 字符数相差较大时编辑距离相似度不可能达到floor，结果只需低于floor
+	if got := normalizedSimilarity(short, long, DuplicateSimilarityThreshold); got >= DuplicateSimilarityThreshold {
+		t.Errorf("normalizedSimilarity with floor = %v, want below %v", got, DuplicateSimilarityThreshold)
+	}
+}
@@ -0,0 +1,141 @@
+/* 标签选择策略服务：决定生成标题时下一个尝试放入的标签，按频道选择策略 */
+package services
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// 标签选择策略名称
+const (
+	TitleStrategyWeighted   = "weighted"    // 按权重随机选择（默认）
+	TitleStrategyRandom     = "random"      // 忽略权重，等概率随机选择
+	TitleStrategyRoundRobin = "round_robin" // 从随机起点开始按标签名顺序轮流选择，同一次请求的多个候选标题接着上一个继续轮换
+	TitleStrategyGreedy     = "greedy"      // 不随机，总是选择能放入剩余长度的最长标签，尽量填满标题
+)
+
+// 标签选择策略
+type TitleStrategy interface {
+	// Pick 从pool中选择下一个尝试放入标题的标签，返回其下标。pool不为空；
+	// fits 判断标签能否放入当前标题的剩余长度；需要随机数时只能使用rng，保证相同的种子得到相同的结果
+	Pick(pool []TagCandidate, fits func(TagCandidate) bool, rng *rand.Rand) int
+}
+
+// 将普通函数适配为 TitleStrategy
+type TitleStrategyFunc func(pool []TagCandidate, fits func(TagCandidate) bool, rng *rand.Rand) int
+
+func (f TitleStrategyFunc) Pick(pool []TagCandidate, fits func(TagCandidate) bool, rng *rand.Rand) int {
+	return f(pool, fits, rng)
+}
+
+// 策略名称 -> 创建策略的函数，参数为频道的长度计算方式。
+// 每次生成都创建新的策略实例，有状态的策略（如轮换）不会在请求之间互相影响
+var titleStrategies = map[string]func(measurer LengthMeasurer) TitleStrategy{
+	TitleStrategyWeighted: func(LengthMeasurer) TitleStrategy {
+		return TitleStrategyFunc(func(pool []TagCandidate, _ func(TagCandidate) bool, rng *rand.Rand) int {
+			return pickWeightedIndex(pool, rng)
+		})
+	},
+	TitleStrategyRandom: func(LengthMeasurer) TitleStrategy {
+		return TitleStrategyFunc(func(pool []TagCandidate, _ func(TagCandidate) bool, rng *rand.Rand) int {
+			return rng.Intn(len(pool))
+		})
+	},
+	TitleStrategyRoundRobin: func(LengthMeasurer) TitleStrategy {
+		return &roundRobinStrategy{}
+	},
+	TitleStrategyGreedy: func(measurer LengthMeasurer) TitleStrategy {
+		return TitleStrategyFunc(func(pool []TagCandidate, fits func(TagCandidate) bool, _ *rand.Rand) int {
+			return pickGreedyIndex(pool, fits, measurer)
+		})
+	},
+}
+
+// RegisterTitleStrategy 注册自定义的标签选择策略，同名策略会被覆盖。应在启动时调用
+func RegisterTitleStrategy(name string, factory func(measurer LengthMeasurer) TitleStrategy) {
+	titleStrategies[name] = factory
+}
+
+// NewTitleStrategy 按名称创建标签选择策略，名称为空时按权重随机选择。
+// measurer 为频道的长度计算方式，为 nil 时按码点数计算
+func NewTitleStrategy(name string, measurer LengthMeasurer) (TitleStrategy, error) {
+	if name == "" {
+		name = TitleStrategyWeighted
+	}
+	factory, ok := titleStrategies[name]
+	if !ok {
+		return nil, fmt.Errorf("不支持的标签选择策略：%s", name)
+	}
+	if measurer == nil {
+		measurer = lengthMeasurers[LengthMetricRunes]
+	}
+	return factory(measurer), nil
+}
+
+// 按权重随机选择一个标签，返回其在tags中的下标。tags不能为空
+func pickWeightedIndex(tags []TagCandidate, rng *rand.Rand) int {
+	total := 0
+	for _, tag := range tags {
+		total += tag.Weight
+	}
+	r := rng.Intn(total)
+	for i, tag := range tags {
+		if r < tag.Weight {
+			return i
+		}
+		r -= tag.Weight
+	}
+	return len(tags) - 1
+}
+
+// 选择能放入剩余长度的最长标签（按 measurer 计算话题标签的长度），长度相同时选择权重高的，再相同时选择靠前的。都放不下时返回0
+func pickGreedyIndex(pool []TagCandidate, fits func(TagCandidate) bool, measurer LengthMeasurer) int {
+	best := -1
+	for i, tag := range pool {
+		if !fits(tag) {
+			continue
+		}
+		if best < 0 {
+			best = i
+			continue
+		}
+		length, bestLength := measurer.Measure("#"+tag.Name), measurer.Measure("#"+pool[best].Name)
+		if length > bestLength || (length == bestLength && tag.Weight > pool[best].Weight) {
+			best = i
+		}
+	}
+	return max(best, 0)
+}
+
+// 轮换策略：第一次随机选择起点，之后总是选择标签名排在上一次之后的第一个标签，到末尾后从头开始。
+// 因长度放不下的标签不计入轮换，下一个候选标题从该标签继续
+type roundRobinStrategy struct {
+	started bool
+	last    string
+}
+
+func (s *roundRobinStrategy) Pick(pool []TagCandidate, fits func(TagCandidate) bool, rng *rand.Rand) int {
+	index := -1
+	if !s.started {
+		s.started = true
+		index = rng.Intn(len(pool))
+	} else {
+		// 标签名排在上一次之后的第一个标签，没有时取标签名最小的标签
+		first := 0
+		for i, tag := range pool {
+			if tag.Name < pool[first].Name {
+				first = i
+			}
+			if tag.Name > s.last && (index < 0 || tag.Name < pool[index].Name) {
+				index = i
+			}
+		}
+		if index < 0 {
+			index = first
+		}
+	}
+	if fits(pool[index]) {
+		s.last = pool[index].Name
+	}
+	return index
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func TestNewTitleStrategy(t *testing.T) {
+	for _, name := range []string{"", TitleStrategyWeighted, TitleStrategyRandom, TitleStrategyRoundRobin, TitleStrategyGreedy} {
+		if _, err := NewTitleStrategy(name, nil); err != nil {
+			t.Errorf("NewTitleStrategy(%q) error: %v", name, err)
+		}
+	}
+	if _, err := NewTitleStrategy("unknown", nil); err == nil {
+		t.Error("NewTitleStrategy(\"unknown\") want error")
+	}
+}
+
+func TestTitleStrategyPick(t *testing.T) {
+	pool := []TagCandidate{
+		{Id: 1, Name: "b", Weight: 1},
+		{Id: 2, Name: "a", Weight: 100},
+		{Id: 3, Name: "longest", Weight: 1},
+		{Id: 4, Name: "c", Weight: 1},
+	}
+	fitsAll := func(TagCandidate) bool { return true }
+	tests := []struct {
+		strategy string
+		measurer string
+		fits     func(TagCandidate) bool
+		want     []string // 连续多次选择（不放回）得到的标签名，为 nil 时只检查下标合法
+	}{
+		{TitleStrategyWeighted, "", fitsAll, nil},
+		{TitleStrategyRandom, "", fitsAll, nil},
+		{TitleStrategyGreedy, "", fitsAll, []string{"longest", "a", "b", "c"}},
+		{TitleStrategyGreedy, "", func(tag TagCandidate) bool { return len(tag.Name) == 1 }, []string{"a", "b", "c"}},
+	}
+	for _, tt := range tests {
+		measurer, _ := NewLengthMeasurer(tt.measurer)
+		strategy, _ := NewTitleStrategy(tt.strategy, measurer)
+		rng := rand.New(rand.NewSource(1))
+		remaining := slices.Clone(pool)
+		var got []string
+		for len(remaining) > 0 {
+			i := strategy.Pick(remaining, tt.fits, rng)
+			if i < 0 || i >= len(remaining) {
+				t.Fatalf("%s Pick returned %d for pool of %d", tt.strategy, i, len(remaining))
+			}
+			if !tt.fits(remaining[i]) {
+				break
+			}
+			got = append(got, remaining[i].Name)
+			remaining = slices.Delete(remaining, i, i+1)
+		}
+		if tt.want != nil && !slices.Equal(got, tt.want) {
+			t.Errorf("%s picked %v, want %v", tt.strategy, got, tt.want)
+		}
+	}
+}
+
+func TestGreedyStrategyUsesMeasurer(t *testing.T) {
+	// 按码点数 "abc" 更长，按显示宽度 "游戏" 更长
+	pool := []TagCandidate{{Id: 1, Name: "abc", Weight: 1}, {Id: 2, Name: "游戏", Weight: 1}}
+	fits := func(TagCandidate) bool { return true }
+	tests := []struct {
+		metric string
+		want   string
+	}{
+		{LengthMetricRunes, "abc"},
+		{LengthMetricWidth, "游戏"},
+	}
+	for _, tt := range tests {
+		measurer, _ := NewLengthMeasurer(tt.metric)
+		strategy, _ := NewTitleStrategy(TitleStrategyGreedy, measurer)
+		if got := pool[strategy.Pick(pool, fits, nil)].Name; got != tt.want {
+			t.Errorf("greedy with %s picked %q, want %q", tt.metric, got, tt.want)
+		}
+	}
+}
+
+func TestRoundRobinStrategy(t *testing.T) {
+	pool := []TagCandidate{{Id: 1, Name: "c"}, {Id: 2, Name: "a"}, {Id: 3, Name: "b"}}
+	strategy, _ := NewTitleStrategy(TitleStrategyRoundRobin, nil)
+	rng := rand.New(rand.NewSource(1))
+	first := pool[strategy.Pick(pool, func(TagCandidate) bool { return true }, rng)].Name
+	next := map[string]string{"a": "b", "b": "c", "c": "a"}
+	last := first
+	for range 5 {
+		got := pool[strategy.Pick(pool, func(TagCandidate) bool { return true }, rng)].Name
+		if got != next[last] {
+			t.Fatalf("round robin picked %q after %q, want %q", got, last, next[last])
+		}
+		last = got
+	}
+	// 放不下的标签不计入轮换，下一次仍从该标签开始
+	skipped := next[last]
+	if got := pool[strategy.Pick(pool, func(TagCandidate) bool { return false }, rng)].Name; got != skipped {
+		t.Fatalf("round robin picked %q, want %q", got, skipped)
+	}
+	if got := pool[strategy.Pick(pool, func(TagCandidate) bool { return true }, rng)].Name; got != skipped {
+		t.Errorf("round robin picked %q after a tag that did not fit, want %q", got, skipped)
+	}
+}
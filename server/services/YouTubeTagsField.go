@@ -0,0 +1,82 @@
+/* YouTube 标签字段生成服务：生成上传视频时填写的逗号分隔“标签”字段 */
+package services
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// YouTube 标签字段的最大字符数，及单个标签的最大字符数
+const (
+	MaxTagsFieldLength = 500
+	MaxTagsFieldTag    = 100
+)
+
+// 按 YouTube 的规则计算单个标签占用的字符数：包含空格的标签会被加上引号，引号同样计入长度
+func tagsFieldCost(tag string) int {
+	cost := utf8.RuneCountInString(tag)
+	if strings.Contains(tag, " ") {
+		cost += 2
+	}
+	return cost
+}
+
+// 按非字母、数字的字符切分主题
+func splitThemeWords(theme string) []string {
+	return strings.FieldsFunc(theme, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.IsMark(r)
+	})
+}
+
+// 将整个主题作为一个短语标签：去掉标点、符号并转换为小写，只有一个单词时返回空字符串（由 themeWords 提供）
+func themePhrase(theme string) string {
+	words := splitThemeWords(theme)
+	if len(words) < 2 {
+		return ""
+	}
+	return strings.ToLower(strings.Join(words, " "))
+}
+
+// 从主题中提取单词作为标签：按非字母、数字的字符切分，并忽略单个字符的单词
+func themeWords(theme string) []string {
+	words := splitThemeWords(theme)
+	result := make([]string, 0, len(words))
+	for _, word := range words {
+		if utf8.RuneCountInString(word) > 1 {
+			result = append(result, strings.ToLower(word))
+		}
+	}
+	return result
+}
+
+// BuildTagsField 生成 YouTube 的标签字段：按顺序放入tags（可以包含多个单词的短语，如触发词）、整个主题组成的短语及主题中的单词，
+// 忽略重复项，放不下或超过100个字符的标签跳过，保证总长度（含逗号分隔符及引号）不超过500个字符。返回标签字段及其按 YouTube 规则计算的长度
+func BuildTagsField(tags []string, theme string) (string, int) {
+	fields := make([]string, 0)
+	seen := make(map[string]bool)
+	length := 0
+	for _, tag := range slices.Concat(tags, []string{themePhrase(theme)}, themeWords(theme)) {
+		// 短语中的连续空白合并为一个空格
+		tag = strings.Join(strings.Fields(tag), " ")
+		key := strings.ToLower(tag)
+		if tag == "" || seen[key] || utf8.RuneCountInString(tag) > MaxTagsFieldTag {
+			continue
+		}
+		cost := tagsFieldCost(tag)
+		if len(fields) > 0 {
+			cost++ // 逗号分隔符
+		}
+		if length+cost > MaxTagsFieldLength {
+			continue
+		}
+		seen[key] = true
+		length += cost
+		if strings.Contains(tag, " ") {
+			tag = `"` + tag + `"`
+		}
+		fields = append(fields, tag)
+	}
+	return strings.Join(fields, ","), length
+}
@@ -0,0 +1,60 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildTagsField(t *testing.T) {
+	tests := []struct {
+		name       string
+		tags       []string
+		theme      string
+		wantField  string
+		wantLength int
+	}{
+		{
+			name:       "单个单词的主题不生成短语",
+			tags:       []string{"gaming"},
+			theme:      "Minecraft",
+			wantField:  "gaming,minecraft",
+			wantLength: 16,
+		},
+		{
+			name:       "多个单词的主题作为短语并加上引号",
+			tags:       []string{"gaming"},
+			theme:      "Boss Fight!",
+			wantField:  `gaming,"boss fight",boss,fight`,
+			wantLength: len(`gaming,"boss fight",boss,fight`),
+		},
+		{
+			name:       "触发词短语加上引号且忽略重复",
+			tags:       []string{"minecraft", "boss  fight", "Minecraft"},
+			theme:      "boss fight",
+			wantField:  `minecraft,"boss fight",boss,fight`,
+			wantLength: len(`minecraft,"boss fight",boss,fight`),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			field, length := BuildTagsField(tt.tags, tt.theme)
+			if field != tt.wantField || length != tt.wantLength {
+				t.Errorf("BuildTagsField() = %q, %d, want %q, %d", field, length, tt.wantField, tt.wantLength)
+			}
+		})
+	}
+}
+
+func TestBuildTagsFieldLimit(t *testing.T) {
+	tags := make([]string, 0)
+	for i := 0; i < 100; i++ {
+		tags = append(tags, strings.Repeat(string(rune('a'+i%26)), 9)+"x"+string(rune('a'+i/26)))
+	}
+	field, length := BuildTagsField(tags, "")
+	if length > MaxTagsFieldLength {
+		t.Fatalf("length = %d, want <= %d", length, MaxTagsFieldLength)
+	}
+	if got := len(field); got != length {
+		t.Errorf("len(field) = %d, want %d", got, length)
+	}
+}
@@ -0,0 +1,86 @@
+// 频道标题模板：在服务端渲染频道配置的标题模板
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+	"time"
+
+	"fswrhzl/ytb_title/server/services"
+)
+
+// 标题模板可用的占位符数据，如 {{.Theme}}、{{.Episode}}、{{.Date}}、{{.Hashtags}}
+type titleTemplateData struct {
+	Theme    string // 视频主题
+	Episode  int    // 集数
+	Date     string // 生成日期，格式为 2006-01-02
+	Hashtags string // 选中的标签，如 "#a #b"
+}
+
+// 解析标题模板。模板为空时返回nil，表示使用默认格式：主题 + 标签
+func parseTitleTemplate(text string) (*template.Template, error) {
+	if text == "" {
+		return nil, nil
+	}
+	tpl, err := template.New("title").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	return tpl, nil
+}
+
+// 校验标题模板：除语法外，未知字段等错误只有在执行时才会暴露，因此使用示例数据执行一次
+func validateTitleTemplate(text string) error {
+	tpl, err := parseTitleTemplate(text)
+	if err != nil {
+		return fmt.Errorf("标题模板格式错误：%v", err)
+	}
+	if tpl == nil {
+		return nil
+	}
+	sample := titleTemplateData{Theme: "theme", Episode: 1, Date: time.Now().Format("2006-01-02"), Hashtags: "#tag"}
+	if err := tpl.Execute(&bytes.Buffer{}, sample); err != nil {
+		return fmt.Errorf("标题模板格式错误：%v", err)
+	}
+	return nil
+}
+
+// 生成标题渲染函数：传入选中的标签字符串，返回加上频道前后缀的完整标题。
+// 未配置模板时，标签按频道设置的位置放入主题；模板中没有使用 {{.Hashtags}} 时，标签按频道设置的位置放入渲染结果
+func newTitleRenderer(tpl *template.Template, data titleTemplateData, decoration services.TitleDecoration) (func(hashtags string) string, error) {
+	placeHashtags := func(base string) func(string) string {
+		return func(hashtags string) string {
+			return decoration.Decorate(base, hashtags)
+		}
+	}
+	if tpl == nil {
+		return placeHashtags(data.Theme), nil
+	}
+	execute := func(hashtags string) (string, error) {
+		var buf bytes.Buffer
+		d := data
+		d.Hashtags = hashtags
+		if err := tpl.Execute(&buf, d); err != nil {
+			return "", err
+		}
+		return buf.String(), nil
+	}
+	base, err := execute("")
+	if err != nil {
+		return nil, fmt.Errorf("渲染标题模板失败：%v", err)
+	}
+	// 使用不同的标签值渲染两次，结果相同说明模板没有使用 {{.Hashtags}}
+	probe, err := execute("#")
+	if err != nil {
+		return nil, fmt.Errorf("渲染标题模板失败：%v", err)
+	}
+	if probe == base {
+		return placeHashtags(base), nil
+	}
+	return func(hashtags string) string {
+		// 模板已在保存时及上面的渲染中校验过，这里不会再出错
+		title, _ := execute(hashtags)
+		return decoration.Prefix + title + decoration.Suffix
+	}, nil
+}